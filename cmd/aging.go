@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// AgingOptions configures the aging report
+type AgingOptions struct {
+	// StaleAfter is the period without activity after which a pr is stale
+	StaleAfter string
+	// Oldest is the number of oldest open prs to list
+	Oldest int
+}
+
+func (a *App) newAgingCommand() *cobra.Command {
+	agingCmd := &cobra.Command{
+		Use:   "aging [repository]",
+		Short: "Report the age of open prs",
+		Long: `List the open prs bucketed by age per label, and the oldest open prs.
+A pr is stale when it had no activity for longer than --stale-after.
+
+Examples:
+  gh pr-stats aging owner/repo
+  gh pr-stats aging owner/repo --stale-after 30d --oldest 20`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runAging,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	agingCmd.Flags().StringVar(&a.Options.Aging.StaleAfter, "stale-after", "", "Mark open prs without activity for this period as stale, e.g. 30d")
+	agingCmd.Flags().IntVarP(&a.Options.Aging.Oldest, "oldest", "n", 10, "Number of oldest open prs to list")
+	agingCmd.Flags().StringVarP(&a.Options.Format, "format", "f", "", "Output format: table (default) or json")
+	agingCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return agingCmd
+}
+
+func (a *App) runAging(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+	format, err := a.commandFormat(render.Table.Name, render.JSON.Name)
+	if err != nil {
+		return err
+	}
+
+	opts := a.Options.Aging
+	var threshold time.Duration
+	if opts.StaleAfter != "" {
+		var err error
+		if threshold, err = utils.ParsePeriod(opts.StaleAfter); err != nil {
+			return err
+		}
+	}
+	if opts.Oldest < 0 {
+		return fmt.Errorf("--oldest must not be negative")
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+
+	prs, err := a.fetchPullRequests(repository)
+	if err != nil {
+		return err
+	}
+
+	report := stats.CalculateAging(prs, time.Now(), threshold, opts.Oldest, a.days)
+
+	switch format {
+	case render.JSON.Name:
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case render.Table.Name:
+		utils.PrintAgingReport(cmd, report)
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/analysis"
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/internal/labels"
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/schema"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slog"
+)
+
+// Fetcher fetches the prs of a repository and their details
+type Fetcher interface {
+	FetchPullRequests(repository string) ([]types.PullRequest, error)
+	SearchPullRequests(repository string, qualifiers []string) ([]types.PullRequest, error)
+	SyncPullRequests(repository string, since *time.Time) ([]types.PullRequest, error)
+	FetchReviews(repository string, prs []types.PullRequest) (map[int][]types.Review, error)
+	FetchSizes(repository string, prs []types.PullRequest) (map[int]types.PullRequestSize, error)
+}
+
+var _ Fetcher = (*github.Client)(nil)
+
+// Options holds the flag values of the commands
+type Options struct {
+	// OutputFile is the file the raw prs are saved to
+	OutputFile string
+	// OutputFormat is the format of the raw prs, one of utils.RawFormats
+	OutputFormat string
+	// Format is the output format of the reports
+	Format string
+	// Debug enables the debug output and hides the spinners
+	Debug bool
+	// Hostname is the GitHub host of the repositories without a host,
+	// GH_HOST or the host gh is authenticated with when empty
+	Hostname string
+	// Remote is the git remote the current repository is taken from
+	Remote string
+	// Concurrency is the number of parallel requests fetching pr details
+	Concurrency int
+	// Profile is the profile of the configuration files to run
+	Profile string
+
+	Report   ReportOptions
+	Analysis analysis.Options
+	Filter   FilterOptions
+	Labels   LabelOptions
+	Calendar CalendarOptions
+
+	Pipeline PipelineOptions
+	Aging    AgingOptions
+	Matrix   MatrixOptions
+	Size     SizeOptions
+	Compare  CompareOptions
+	Check    CheckOptions
+	Serve    ServeOptions
+}
+
+// defaultConcurrency is the number of parallel requests fetching pr details
+// unless configured otherwise
+const defaultConcurrency = 4
+
+// App runs the commands with its fetcher, renderer and logger. Every field
+// can be replaced before the commands are created, e.g. by tests.
+type App struct {
+	// Fetcher is created from the gh credentials on first use when nil
+	Fetcher Fetcher
+	// Renderers are the output formats of the statistics
+	Renderers *render.Registry
+	Logger    *slog.Logger
+	Options   Options
+
+	logLevel *slog.LevelVar
+	// configRepositories are the repositories of the configuration files
+	configRepositories []string
+	// labels normalizes the labels, set up from the label flags
+	labels *labels.Normalizer
+	// days measures the durations, set up from the calendar flags
+	days stats.DaysFunc
+	// location is the location of the --timezone flag
+	location *time.Location
+}
+
+// NewApp creates the app of the command line, logging to stderr so that
+// stdout only holds the output
+func NewApp() *App {
+	level := new(slog.LevelVar)
+	return &App{
+		Renderers: render.Default,
+		Options:   Options{Concurrency: defaultConcurrency},
+		Logger:    slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})),
+		logLevel:  level,
+	}
+}
+
+// setup applies the configuration files and configures logging, label
+// normalization and the business calendar before running a command
+func (a *App) setup(cmd *cobra.Command) error {
+	if err := a.applyConfig(cmd); err != nil {
+		return err
+	}
+
+	if a.logLevel != nil && a.Options.Debug {
+		a.logLevel.Set(slog.LevelDebug)
+	}
+
+	if err := a.setupLabels(); err != nil {
+		return err
+	}
+	days, err := a.setupCalendar()
+	if err != nil {
+		return err
+	}
+	a.days = days
+	if a.location, err = a.setupTimezone(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// progress returns the progress of the long running steps, showing a
+// spinner unless in debug mode
+func (a *App) progress() *utils.Progress {
+	return &utils.Progress{Logger: a.Logger, Spinner: !a.Options.Debug}
+}
+
+// saveOptions returns the options saving the raw prs in the format
+func (a *App) saveOptions(format string) utils.SaveOptions {
+	return utils.SaveOptions{Format: format, Days: a.days, Progress: a.progress()}
+}
+
+// fetcher returns the fetcher, creating the GitHub client on first use so
+// that commands which do not fetch work without credentials
+func (a *App) fetcher() (Fetcher, error) {
+	if a.Fetcher == nil {
+		if a.Options.Concurrency < 1 {
+			return nil, fmt.Errorf("--concurrency must be positive")
+		}
+		client, err := github.NewClient(github.ClientOptions{
+			Host:        a.Options.Hostname,
+			Remote:      a.Options.Remote,
+			Concurrency: a.Options.Concurrency,
+			Progress:    !a.Options.Debug,
+			Logger:      a.Logger,
+		})
+		if err != nil {
+			return nil, err
+		}
+		a.Fetcher = client
+	}
+	return a.Fetcher, nil
+}
+
+// newReport wraps the statistics of the repositories in a report with the
+// version of the tool and the filter flags
+func (a *App) newReport(repositories []string, statistics types.Statistics) types.Report {
+	report := types.NewReport(statistics)
+	report.ToolVersion = Version
+	if repositories != nil {
+		report.Repositories = repositories
+	}
+	filters := a.Options.Filter
+	report.Filters = types.ReportFilters{
+		Filter:         filters.Expr,
+		ExcludeAuthors: filters.Authors.Exclude,
+		ExcludeBots:    filters.Authors.ExcludeBots,
+		OnlyBots:       filters.Authors.OnlyBots,
+		GroupBy:        a.Options.Analysis.GroupBy,
+	}
+	if filters.Since != "" {
+		if start, err := utils.ParseSince(filters.Since, time.Now()); err == nil {
+			start = start.UTC().Truncate(time.Second)
+			report.Filters.Since = &start
+		}
+	}
+	return report
+}
+
+// renderStatistics writes the report in the --format output format
+func (a *App) renderStatistics(cmd *cobra.Command, report types.Report, columns []utils.Column) error {
+	return a.Renderers.Render(cmd.OutOrStdout(), report, a.Options.Format, render.Options{Columns: columns, Legacy: a.Options.Report.LegacyJSON})
+}
+
+// commandFormat returns the name of the --format format of a command
+// supporting only some of the formats of the registry
+func (a *App) commandFormat(supported ...string) (string, error) {
+	format, err := a.Renderers.Lookup(a.Options.Format)
+	if err == nil && !slices.Contains(supported, format.Name) {
+		err = fmt.Errorf("unsupported format %q. Supported formats: %s", a.Options.Format, strings.Join(supported, ", "))
+	}
+	if err != nil {
+		return "", err
+	}
+	return format.Name, nil
+}
+
+// statsTarget is a file the statistics are saved to
+type statsTarget struct {
+	filename string
+	format   render.Format
+}
+
+// statsTargets returns the files with the format of --stats-format, or of
+// their extension, JSON when the extension is not the one of a format
+func (a *App) statsTargets(filenames []string) ([]statsTarget, error) {
+	targets := make([]statsTarget, 0, len(filenames))
+	for _, filename := range filenames {
+		format, ok := a.Renderers.ForFile(filename)
+		if a.Options.Report.StatsFormat != "" || !ok {
+			name := a.Options.Report.StatsFormat
+			if name == "" {
+				name = render.JSON.Name
+			}
+
+			var err error
+			if format, err = a.Renderers.Lookup(name); err != nil {
+				return nil, fmt.Errorf("invalid stats format: %v", err)
+			}
+		}
+		targets = append(targets, statsTarget{filename: filename, format: format})
+	}
+	return targets, nil
+}
+
+// saveStatistics writes the report to every target
+func (a *App) saveStatistics(targets []statsTarget, report types.Report, columns []utils.Column) error {
+	for _, target := range targets {
+		file, err := os.Create(target.filename)
+		if err != nil {
+			return fmt.Errorf("failed to create file %s: %v", target.filename, err)
+		}
+
+		err = target.format.Renderer.Render(file, report, render.Options{Columns: columns, Legacy: a.Options.Report.LegacyJSON})
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write to file %s: %v", target.filename, err)
+		}
+
+		a.Logger.Debug(fmt.Sprintf("Statistics saved to %s as %s", target.filename, target.format.Name))
+	}
+	return nil
+}
+
+// printJSONSchema writes the JSON Schema of the json report
+func printJSONSchema(cmd *cobra.Command) error {
+	data, err := schema.Generate(types.Report{}, "gh-pr-stats report")
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON Schema: %v", err)
+	}
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/calendar"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/spf13/pflag"
+)
+
+// CalendarOptions configures the business calendar durations are measured in
+type CalendarOptions struct {
+	BusinessTime bool
+	Timezone     string
+	WorkingHours string
+	// Weekend is the comma separated list of non-working weekdays
+	Weekend string
+	// Holidays is an ICS or YAML file listing holidays
+	Holidays string
+}
+
+func (a *App) addCalendarFlags(flags *pflag.FlagSet) {
+	opts := &a.Options.Calendar
+	flags.BoolVar(&opts.BusinessTime, "business-time", false, "Measure durations in working days of the business calendar")
+	flags.StringVar(&opts.Timezone, "timezone", "UTC", "Timezone of the working hours and the created hours of insights, e.g. Europe/Berlin")
+	flags.StringVar(&opts.WorkingHours, "working-hours", "09:00-17:00", "Working hours of a working day")
+	flags.StringVar(&opts.Weekend, "weekend", "sat,sun", "Comma separated non-working weekdays")
+	flags.StringVar(&opts.Holidays, "holidays", "", "ICS or YAML file listing holidays")
+}
+
+// setupCalendar returns the function measuring the durations, in business
+// time when requested
+func (a *App) setupCalendar() (stats.DaysFunc, error) {
+	opts := a.Options.Calendar
+	if !opts.BusinessTime {
+		return stats.WallClockDays, nil
+	}
+
+	cal := calendar.Default()
+	if err := cal.SetTimezone(opts.Timezone); err != nil {
+		return nil, err
+	}
+	if err := cal.SetWorkingHours(opts.WorkingHours); err != nil {
+		return nil, err
+	}
+	if err := cal.SetWeekend(opts.Weekend); err != nil {
+		return nil, err
+	}
+	if opts.Holidays != "" {
+		if err := cal.LoadHolidays(opts.Holidays); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats.CalendarDays(cal), nil
+}
+
+// setupTimezone returns the location of the --timezone flag
+func (a *App) setupTimezone() (*time.Location, error) {
+	cal := calendar.Default()
+	if err := cal.SetTimezone(a.Options.Calendar.Timezone); err != nil {
+		return nil, err
+	}
+	return cal.Location, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/internal/check"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the check command
+const (
+	exitCodeError     = 1
+	exitCodeViolation = 2
+)
+
+// CheckOptions holds the threshold rules of the check command
+type CheckOptions struct {
+	// FailOn are the rules failing the check when true
+	FailOn []string
+}
+
+// violationError is returned when at least one threshold rule failed
+type violationError struct {
+	failed int
+}
+
+func (e *violationError) Error() string {
+	return fmt.Sprintf("%d threshold rule(s) violated", e.failed)
+}
+
+func (a *App) newCheckCommand() *cobra.Command {
+	checkCmd := &cobra.Command{
+		Use:   "check [repository]",
+		Short: "Fail when pr statistics violate thresholds",
+		Long: fmt.Sprintf(`Evaluate threshold rules against the pr statistics and exit with a non-zero code when any is violated.
+A rule fails when its condition is true.
+
+Rules can also be listed under thresholds.fail-on in the configuration file.
+
+Rules have the form [overall.|label[name].]metric operator number
+  metrics:   %s
+  operators: >, >=, <, <=, ==, !=
+
+Exit codes:
+  0  every rule passed
+  1  runtime error
+  2  at least one rule was violated
+
+Examples:
+  gh pr-stats check owner/repo \
+    --fail-on 'overall.median_days_to_close > 3' \
+    --fail-on 'label[bug].open > 20' \
+    --fail-on 'open_percentage > 15'`, strings.Join(check.MetricNames(), ", ")),
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runCheck,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	checkCmd.Flags().StringArrayVar(&a.Options.Check.FailOn, "fail-on", nil, "Rule that fails the check when true (repeatable)")
+	checkCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return checkCmd
+}
+
+func (a *App) runCheck(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+
+	failOn := a.Options.Check.FailOn
+	if len(failOn) == 0 {
+		return fmt.Errorf("at least one --fail-on rule is required")
+	}
+	rules, err := check.ParseRules(failOn)
+	if err != nil {
+		return err
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+
+	prs, err := a.fetchPullRequests(repository)
+	if err != nil {
+		return err
+	}
+
+	results, failed := check.Evaluate(rules, stats.CalculateStatistics(prs, a.days))
+	utils.PrintCheckResults(cmd, results)
+
+	if failed {
+		count := 0
+		for _, result := range results {
+			if result.Failed {
+				count++
+			}
+		}
+		return &violationError{failed: count}
+	}
+
+	return nil
+}
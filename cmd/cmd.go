@@ -1,121 +1,215 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/shufo/gh-pr-stats/internal/analysis"
 	"github.com/shufo/gh-pr-stats/internal/github"
-	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/storage"
 	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
 	"github.com/spf13/cobra"
 )
 
-var (
-	outputFile string
-	statsFile  string
-	format     string
-	debug      bool
-
-	Version = "dev"
-)
+var Version = "dev"
+
+// ReportOptions selects the columns of the statistics and the files they
+// are saved to
+type ReportOptions struct {
+	// Columns is the comma separated list of columns to output
+	Columns     string
+	ListColumns bool
+	ListFormats bool
+	// LegacyJSON writes the json statistics in the shape of schema version 1
+	LegacyJSON bool
+	JSONSchema bool
+
+	// StatsFiles are the files the statistics are saved to
+	StatsFiles []string
+	// StatsFormat overrides the format of the extension of every stats file
+	StatsFormat string
+	// SQLiteFile is the database the prs, reviews and statistics are upserted into
+	SQLiteFile string
+}
 
 func Exec() {
+	if err := NewApp().Command().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		var violation *violationError
+		if errors.As(err, &violation) {
+			os.Exit(exitCodeViolation)
+		}
+		os.Exit(exitCodeError)
+	}
+}
+
+// Command returns the root command running the app, with every subcommand
+func (a *App) Command() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "gh pr-stats [repository]",
 		Short: "Generate GitHub pr statistics",
 		Long: `A GitHub CLI extension to analyze repository prs and generate statistics.
 Provides detailed information about prs grouped by labels and overall statistics.
 
+The command runs the whole pipeline. Use the fetch, analyze and report commands
+to run and cache the stages separately.
+
 Examples:
   # Current repository
   gh pr-stats
 
   # Specific repository
   gh pr-stats owner/repo
+  gh pr-stats https://github.com/owner/repo
 
   # With output format
-  gh pr-stats owner/repo --format json`,
+  gh pr-stats owner/repo --format json
+
+  # With selected columns
+  gh pr-stats owner/repo --columns label,open,merged,p90
+
+  # Stage by stage
+  gh pr-stats fetch owner/repo
+  gh pr-stats analyze owner/repo --output stats.json
+  gh pr-stats report --input stats.json --format markdown`,
 		Args:          cobra.MaximumNArgs(1),
-		RunE:          runCommand,
+		RunE:          a.runCommand,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		Version:       getVersion(),
 	}
 
-	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file for raw prs data (optional)")
-	rootCmd.Flags().StringVarP(&statsFile, "stats", "s", "", "Output file for statistics data (optional)")
-	rootCmd.Flags().StringVarP(&format, "format", "f", "", "Output format: table (default), json, csv, or tsv")
-	rootCmd.Flags().BoolVarP(&debug, "debug", "v", false, "Enable verbose debug output")
+	rootCmd.Flags().StringVarP(&a.Options.OutputFile, "output", "o", "", "Output file for raw prs data (optional)")
+	rootCmd.Flags().StringVar(&a.Options.OutputFormat, "output-format", "json", "Format of the raw prs data: "+strings.Join(utils.RawFormats, ", "))
+	rootCmd.Flags().StringArrayVarP(&a.Options.Report.StatsFiles, "stats", "s", nil, "Output file for statistics data in the format of its extension, json otherwise (repeatable)")
+	rootCmd.Flags().StringVar(&a.Options.Report.StatsFormat, "stats-format", "", "Format of every --stats file instead of the one of its extension")
+	rootCmd.Flags().StringVar(&a.Options.Report.SQLiteFile, "sqlite", "", "SQLite database to upsert prs, labels, reviews and statistics into (optional)")
+	a.addAnalyzeFlags(rootCmd.Flags())
+	a.addReportFlags(rootCmd.Flags())
+	rootCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	rootCmd.PersistentFlags().StringVar(&a.Options.Hostname, "hostname", "", "GitHub host of the repositories without a HOST/ prefix (default: GH_HOST or the host gh is authenticated with)")
+	rootCmd.PersistentFlags().IntVar(&a.Options.Concurrency, "concurrency", defaultConcurrency, "Number of parallel requests fetching pr details such as reviews and sizes")
+	rootCmd.PersistentFlags().StringVar(&a.Options.Remote, "remote", "", "Git remote of the current repository used when no repository is given (default: upstream, github, origin or the first remote)")
+	rootCmd.PersistentFlags().StringVar(&a.Options.Profile, "profile", "", "Named profile of the configuration files to run, e.g. weekly")
+	a.addCalendarFlags(rootCmd.PersistentFlags())
+	a.addFilterFlags(rootCmd.PersistentFlags())
+	a.addLabelFlags(rootCmd.PersistentFlags())
+
+	rootCmd.AddCommand(a.newFetchCommand())
+	rootCmd.AddCommand(a.newAnalyzeCommand())
+	rootCmd.AddCommand(a.newReportCommand())
+	rootCmd.AddCommand(a.newServeCommand())
+	rootCmd.AddCommand(a.newCheckCommand())
+	rootCmd.AddCommand(a.newCompareCommand())
+	rootCmd.AddCommand(a.newAgingCommand())
+	rootCmd.AddCommand(a.newSizeCommand())
+	rootCmd.AddCommand(a.newInsightsCommand())
+	rootCmd.AddCommand(a.newLabelsCommand())
+	rootCmd.AddCommand(a.newConfigCommand())
 
 	// Customize version template
 	rootCmd.SetVersionTemplate(`gh-pr-stats {{printf "version: %s" .Version}}
 `)
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	return rootCmd
 }
 
-func runCommand(cmd *cobra.Command, args []string) error {
-	utils.SetupLogger(cmd, debug)
-	utils.SetDebug(debug)
-	github.SetDebug(debug)
-
-	var repository string
-	if len(args) > 0 {
-		repository = args[0]
-		// Validate repository format
-		if !isValidRepositoryFormat(repository) {
-			return fmt.Errorf("invalid repository format. Expected format: owner/repo")
-		}
+func (a *App) runCommand(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+
+	if a.Options.Report.ListColumns {
+		utils.PrintColumns(cmd)
+		return nil
+	}
+	if a.Options.Report.ListFormats {
+		render.PrintFormats(cmd.OutOrStdout(), a.Renderers)
+		return nil
 	}
+	if a.Options.Report.JSONSchema {
+		return printJSONSchema(cmd)
+	}
+
+	columns, err := utils.ParseColumns(a.Options.Report.Columns)
+	if err != nil {
+		return err
+	}
+	if _, err := a.Renderers.Lookup(a.Options.Format); err != nil {
+		return err
+	}
+	targets, err := a.statsTargets(a.Options.Report.StatsFiles)
+	if err != nil {
+		return err
+	}
+	analysisOpts, err := a.analysisOptions()
+	if err != nil {
+		return err
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+	if !utils.IsValidRawFormat(a.Options.OutputFormat) {
+		return fmt.Errorf("invalid output format %q. Supported formats: %s", a.Options.OutputFormat, strings.Join(utils.RawFormats, ", "))
+	}
+
 	// Fetch prs
-	prs, err := github.FetchPullRequests(repository)
+	prs, err := a.fetchPullRequests(repository)
 	if err != nil {
 		return err
 	}
 
 	// Save prs if output file is specified
-	if outputFile != "" {
-		if err := utils.SaveToFile(prs, outputFile); err != nil {
+	if a.Options.OutputFile != "" {
+		if err := utils.SavePullRequests(prs, a.Options.OutputFile, a.saveOptions(a.Options.OutputFormat)); err != nil {
 			return err
 		}
 	}
 
 	// Calculate statistics
-	stats := stats.CalculateStatistics(prs)
+	allStats, stats, err := analysis.Analyze(prs, analysisOpts)
+	if err != nil {
+		return err
+	}
 
-	// Save statistics if stats file is specified
-	if statsFile != "" {
-		if err := utils.SaveToFile(stats, statsFile); err != nil {
+	// Upsert everything into the SQLite database if specified
+	if a.Options.Report.SQLiteFile != "" {
+		if err := a.saveToSQLite(repository, prs, allStats); err != nil {
 			return err
 		}
 	}
 
-	// Output based on format
-	switch strings.ToLower(format) {
-	case "json":
-		encoder := json.NewEncoder(cmd.OutOrStdout())
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(stats)
-	case "csv":
-		return utils.WriteDelimitedOutput(cmd, stats, ',')
-	case "tsv":
-		return utils.WriteDelimitedOutput(cmd, stats, '\t')
-	default:
-		utils.PrintStatistics(cmd, stats)
+	// Save statistics to every stats file
+	report := a.newReport([]string{github.Qualify(repository, a.Options.Hostname)}, stats)
+	if err := a.saveStatistics(targets, report, columns); err != nil {
+		return err
 	}
 
-	return nil
+	return a.renderStatistics(cmd, report, columns)
 }
 
-// isValidRepositoryFormat validates the repository argument format
-func isValidRepositoryFormat(repo string) bool {
-	parts := strings.Split(repo, "/")
-	return len(parts) == 2 && parts[0] != "" && parts[1] != ""
+// saveToSQLite fetches the reviews of the prs and writes everything to the
+// --sqlite database, keyed by the qualified repository so that every spelling of
+// the same repository shares its rows
+func (a *App) saveToSQLite(repository string, prs []types.PullRequest, stats types.Statistics) error {
+	fetcher, err := a.fetcher()
+	if err != nil {
+		return err
+	}
+	reviews, err := fetcher.FetchReviews(repository, prs)
+	if err != nil {
+		return err
+	}
+
+	return storage.SaveToSQLite(a.Options.Report.SQLiteFile, github.Qualify(repository, a.Options.Hostname), prs, reviews, stats, a.progress())
 }
 
 // getVersion returns the version string
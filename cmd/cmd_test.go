@@ -2,16 +2,39 @@ package cmd
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/shufo/gh-pr-stats/internal/compare"
 	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/internal/render"
 	"github.com/shufo/gh-pr-stats/pkg/types"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
 )
 
+func TestMain(m *testing.M) {
+	// Keep the user configuration and cache of the machine out of the tests
+	dir, err := os.MkdirTemp("", "gh-pr-stats-config")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 // Helper function to create test prs
 func createTestPullRequests() []types.PullRequest {
 	now := time.Now()
@@ -39,21 +62,103 @@ func createTestPullRequests() []types.PullRequest {
 	}
 }
 
-// Helper to setup test command with mocked FetchPullRequests
-func setupTestCommand() (*cobra.Command, *bytes.Buffer) {
+// Helper function to create test prs by humans and bots
+func createAuthoredPullRequests() []types.PullRequest {
+	prs := append(createTestPullRequests(), createTestPullRequests()...)
+	prs[0].User = types.User{Login: "octocat", Type: "User"}
+	prs[1].User = types.User{Login: "someone", Type: "User"}
+	prs[2].User = types.User{Login: "dependabot[bot]", Type: "Bot"}
+	prs[3].User = types.User{Login: "renovate", Type: "Bot"}
+	return prs
+}
+
+// Helper function to create test prs with inconsistent label names
+func createMixedLabelPullRequests() []types.PullRequest {
+	names := [][]string{{"bug"}, {"Bug", "area/frontend"}, {"type: bug"}, {"kind/bug", "area/backend"}, {"area/frontend", "wontfix"}}
+	prs := make([]types.PullRequest, len(names))
+	for i, labels := range names {
+		prs[i] = createTestPullRequests()[0]
+		prs[i].Labels = nil
+		for _, name := range labels {
+			prs[i].Labels = append(prs[i].Labels, types.Label{Name: name})
+		}
+	}
+	return prs
+}
+
+// mockFetcher serves prs from the given functions and fails the calls
+// without one
+type mockFetcher struct {
+	fetch   func(string) ([]types.PullRequest, error)
+	search  func(string, []string) ([]types.PullRequest, error)
+	reviews func(string, []types.PullRequest) (map[int][]types.Review, error)
+	sizes   func(string, []types.PullRequest) (map[int]types.PullRequestSize, error)
+}
+
+func (f *mockFetcher) FetchPullRequests(repository string) ([]types.PullRequest, error) {
+	if f.fetch == nil {
+		return nil, errors.New("FetchPullRequests is not mocked")
+	}
+	return f.fetch(repository)
+}
+
+func (f *mockFetcher) SearchPullRequests(repository string, qualifiers []string) ([]types.PullRequest, error) {
+	if f.search == nil {
+		return nil, errors.New("SearchPullRequests is not mocked")
+	}
+	return f.search(repository, qualifiers)
+}
+
+func (f *mockFetcher) SyncPullRequests(repository string, since *time.Time) ([]types.PullRequest, error) {
+	return f.FetchPullRequests(repository)
+}
+
+func (f *mockFetcher) FetchReviews(repository string, prs []types.PullRequest) (map[int][]types.Review, error) {
+	if f.reviews == nil {
+		return nil, errors.New("FetchReviews is not mocked")
+	}
+	return f.reviews(repository, prs)
+}
+
+func (f *mockFetcher) FetchSizes(repository string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+	if f.sizes == nil {
+		return nil, errors.New("FetchSizes is not mocked")
+	}
+	return f.sizes(repository, prs)
+}
+
+// Helper to create an app fetching from the mock and discarding the logs
+func newTestApp(fetcher Fetcher) *App {
+	app := NewApp()
+	app.Fetcher = fetcher
+	app.Logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	return app
+}
+
+// Helper to setup the root command of an app fetching from the mock
+func setupTestCommand(fetcher *mockFetcher) (*cobra.Command, *bytes.Buffer) {
+	app := newTestApp(fetcher)
 	buf := new(bytes.Buffer)
 	cmd := &cobra.Command{
 		Use:          "gh pr-stats [repository]",
-		RunE:         runCommand,
+		RunE:         app.runCommand,
 		SilenceUsage: true,
 	}
 	cmd.SetOutput(buf)
 
 	// Add flags
-	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "")
-	cmd.Flags().StringVarP(&statsFile, "stats", "s", "", "")
-	cmd.Flags().StringVarP(&format, "format", "f", "", "")
-	cmd.Flags().BoolVarP(&debug, "debug", "v", false, "")
+	cmd.Flags().StringVarP(&app.Options.OutputFile, "output", "o", "", "")
+	cmd.Flags().StringVar(&app.Options.OutputFormat, "output-format", "json", "")
+	cmd.Flags().StringArrayVarP(&app.Options.Report.StatsFiles, "stats", "s", nil, "")
+	cmd.Flags().StringVar(&app.Options.Report.StatsFormat, "stats-format", "", "")
+	cmd.Flags().StringVar(&app.Options.Report.SQLiteFile, "sqlite", "", "")
+	app.addAnalyzeFlags(cmd.Flags())
+	app.addReportFlags(cmd.Flags())
+	cmd.Flags().BoolVarP(&app.Options.Debug, "debug", "v", false, "")
+	cmd.Flags().StringVar(&app.Options.Profile, "profile", "", "")
+	app.addCalendarFlags(cmd.Flags())
+	app.addFilterFlags(cmd.Flags())
+	app.addLabelFlags(cmd.Flags())
 
 	return cmd, buf
 }
@@ -63,7 +168,7 @@ func TestRunCommandWithMock(t *testing.T) {
 		name           string
 		args           []string
 		format         string
-		mockFetch      github.FetchPullRequestsFunc
+		mockFetch      func(string) ([]types.PullRequest, error)
 		expectError    bool
 		validateOutput func(*testing.T, []byte)
 	}{
@@ -76,9 +181,7 @@ func TestRunCommandWithMock(t *testing.T) {
 				return createTestPullRequests(), nil
 			},
 			validateOutput: func(t *testing.T, output []byte) {
-				var stats types.Statistics
-				err := json.Unmarshal(output, &stats)
-				assert.NoError(t, err, "Failed to parse JSON output")
+				stats := parseReport(t, output)
 
 				// Validate overall stats
 				assert.Equal(t, 2, stats.OverallStats.Total, "Total prs should be 2")
@@ -104,6 +207,202 @@ func TestRunCommandWithMock(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "CSV output with selected columns",
+			args:   []string{"owner/repo", "--columns", "label, merged,p90"},
+			format: "csv",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				prs := createTestPullRequests()
+				prs[1].PullRequest = &types.PullRequestRef{MergedAt: prs[1].ClosedAt}
+				return prs, nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"Label", "Merged", "P90 Time to close (days)"}, records[0])
+				assert.Contains(t, records, []string{"test_enhancement", "1", "1"})
+				assert.Equal(t, []string{"Total", "1", "1"}, records[len(records)-1])
+			},
+		},
+		{
+			name:   "Sorted, limited and filtered label rows",
+			args:   []string{"owner/repo", "--sort-by", "label:desc", "--top", "1", "--min-count", "1"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				prs := createTestPullRequests()
+				prs = append(prs, types.PullRequest{Title: "Test PullRequest 3", State: "open", CreatedAt: prs[0].CreatedAt})
+				prs[1].Labels = append(prs[1].Labels, types.Label{Name: "test_bug"})
+				return prs, nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Equal(t, 3, stats.OverallStats.Total)
+				assert.Len(t, stats.LabelStats, 2)
+				assert.Equal(t, "test_enhancement", stats.LabelStats[0].Name)
+				// test_bug and *unlabeled* are aggregated with each pr counted once
+				assert.Equal(t, "*other*", stats.LabelStats[1].Name)
+				assert.Equal(t, 3, stats.LabelStats[1].Total)
+				assert.Equal(t, 1, stats.LabelStats[1].Closed)
+			},
+		},
+		{
+			name:   "Business time excludes nights and weekends",
+			args:   []string{"owner/repo", "--business-time", "--working-hours", "09:00-17:00"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				// Friday 16:00 to Monday 10:00 is two working hours
+				createdAt := time.Date(2024, 12, 6, 16, 0, 0, 0, time.UTC)
+				closedAt := time.Date(2024, 12, 9, 10, 0, 0, 0, time.UTC)
+				return []types.PullRequest{{State: "closed", CreatedAt: &createdAt, ClosedAt: &closedAt}}, nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Equal(t, 0.25, stats.OverallStats.AvgDaysToClose)
+			},
+		},
+		{
+			name:   "Exclude bots and authors",
+			args:   []string{"owner/repo", "--exclude-bots", "--exclude-author", "Someone"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				return createAuthoredPullRequests(), nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Equal(t, 1, stats.OverallStats.Total)
+			},
+		},
+		{
+			name:   "Group by author type",
+			args:   []string{"owner/repo", "--group-by", "author-type"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				return createAuthoredPullRequests(), nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Equal(t, 4, stats.OverallStats.Total)
+				assert.Equal(t, []string{"bot", "human"}, []string{stats.LabelStats[0].Name, stats.LabelStats[1].Name})
+				assert.Equal(t, 2, stats.LabelStats[0].Total)
+				assert.Equal(t, 2, stats.LabelStats[1].Total)
+			},
+		},
+		{
+			name:   "Contradicting bot filters",
+			args:   []string{"owner/repo", "--exclude-bots", "--only-bots"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				t.Fatal("FetchPullRequests should not be called")
+				return nil, nil
+			},
+			expectError: true,
+		},
+		{
+			name:   "Filter expression evaluated locally",
+			args:   []string{"owner/repo", "--filter", "(label:test_bug OR is:bot) AND NOT author:someone", "--no-pushdown"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				return createAuthoredPullRequests(), nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Equal(t, 3, stats.OverallStats.Total)
+				assert.Equal(t, 2, stats.OverallStats.Open)
+			},
+		},
+		{
+			name:   "Normalize labels with aliases and case folding",
+			args:   []string{"owner/repo", "--label-ignore-case", "--label-alias", "bug=type: bug,kind/bug", "--exclude-labels", "^wont"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				return createMixedLabelPullRequests(), nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Equal(t, 5, stats.OverallStats.Total)
+				names := make([]string, len(stats.LabelStats))
+				for i, stat := range stats.LabelStats {
+					names[i] = stat.Name
+				}
+				assert.Equal(t, []string{"bug", "area/frontend", "area/backend"}, names)
+				assert.Equal(t, 4, stats.LabelStats[0].Total)
+			},
+		},
+		{
+			name:   "Group by label prefix",
+			args:   []string{"owner/repo", "--label-prefix", "area/", "--include-labels", "front"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				return createMixedLabelPullRequests(), nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				assert.Len(t, stats.LabelStats, 2)
+				assert.Equal(t, types.UnlabeledLabel, stats.LabelStats[0].Name)
+				assert.Equal(t, 3, stats.LabelStats[0].Total)
+				assert.Equal(t, "area/frontend", stats.LabelStats[1].Name)
+				assert.Equal(t, 2, stats.LabelStats[1].Total)
+			},
+		},
+		{
+			name:   "Group by primary label",
+			args:   []string{"owner/repo", "--group-by", "primary-label", "--label-priority", "area/frontend,bug"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				return createMixedLabelPullRequests(), nil
+			},
+			validateOutput: func(t *testing.T, output []byte) {
+				stats := parseReport(t, output)
+				sum := 0
+				totals := make(map[string]int)
+				for _, stat := range stats.LabelStats {
+					sum += stat.Total
+					totals[stat.Name] = stat.Total
+				}
+				assert.Equal(t, stats.OverallStats.Total, sum, "Label rows should add up to the total")
+				assert.Equal(t, map[string]int{"area/frontend": 2, "bug": 1, "type: bug": 1, "area/backend": 1}, totals)
+			},
+		},
+		{
+			name:   "Invalid label pattern",
+			args:   []string{"owner/repo", "--include-labels", "area/("},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				t.Fatal("FetchPullRequests should not be called")
+				return nil, nil
+			},
+			expectError: true,
+		},
+		{
+			name:   "Invalid filter expression",
+			args:   []string{"owner/repo", "--filter", "label:bug AND (author:octocat"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				t.Fatal("FetchPullRequests should not be called")
+				return nil, nil
+			},
+			expectError: true,
+		},
+		{
+			name:   "Base filter nested in OR",
+			args:   []string{"owner/repo", "--filter", "base:main OR label:bug"},
+			format: "json",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				t.Fatal("FetchPullRequests should not be called")
+				return nil, nil
+			},
+			expectError: true,
+		},
+		{
+			name:   "Unknown column",
+			args:   []string{"owner/repo", "--columns", "label,unknown"},
+			format: "csv",
+			mockFetch: func(repo string) ([]types.PullRequest, error) {
+				t.Fatal("FetchPullRequests should not be called")
+				return nil, nil
+			},
+			expectError: true,
+		},
 		{
 			name:   "Invalid repository format",
 			args:   []string{"invalid-repo"},
@@ -118,13 +417,9 @@ func TestRunCommandWithMock(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			originalFetch := github.SetFetchPullRequestsFunc(tt.mockFetch)
-			defer github.SetFetchPullRequestsFunc(originalFetch)
-
-			cmd, buf := setupTestCommand()
-			format = tt.format
+			cmd, buf := setupTestCommand(&mockFetcher{fetch: tt.mockFetch})
 
-			cmd.SetArgs(tt.args)
+			cmd.SetArgs(append(tt.args, "--format", tt.format))
 			err := cmd.Execute()
 
 			if tt.expectError {
@@ -139,3 +434,988 @@ func TestRunCommandWithMock(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONReport(t *testing.T) {
+	fetcher := &mockFetcher{
+		fetch: func(repo string) ([]types.PullRequest, error) {
+			return createTestPullRequests(), nil
+		},
+	}
+	run := func(args ...string) []byte {
+		buf := new(bytes.Buffer)
+		cmd := newTestApp(fetcher).Command()
+		cmd.SetOutput(buf)
+		cmd.SetArgs(args)
+		assert.NoError(t, cmd.Execute())
+		return buf.Bytes()
+	}
+
+	var report types.Report
+	assert.NoError(t, json.Unmarshal(run("owner/repo", "--format", "json", "--exclude-bots", "--since", "2000-01-01"), &report))
+	assert.Equal(t, types.SchemaVersion, report.SchemaVersion)
+	assert.Equal(t, Version, report.ToolVersion)
+	assert.False(t, report.GeneratedAt.IsZero())
+	assert.Equal(t, []string{"owner/repo"}, report.Repositories)
+	assert.True(t, report.Filters.ExcludeBots)
+	assert.Equal(t, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), report.Filters.Since.UTC())
+	assert.Equal(t, "label", report.Filters.GroupBy)
+	assert.Equal(t, 2, report.Statistics.OverallStats.Total)
+
+	// The legacy shape has no envelope and the keys of schema version 1
+	legacy := run("owner/repo", "--format", "json", "--legacy-json")
+	assert.NotContains(t, string(legacy), "schemaVersion")
+	assert.Contains(t, string(legacy), `"AvgDaysToClose"`)
+
+	// Files of schema version 1 are still read
+	legacyFile := filepath.Join(t.TempDir(), "legacy.json")
+	assert.NoError(t, os.WriteFile(legacyFile, legacy, 0o644))
+	assert.Equal(t, "Label,Total\ntest_bug,1\ntest_enhancement,1\nTotal,2\n",
+		string(run("report", "--input", legacyFile, "--format", "csv", "--columns", "label,total")))
+	stats, err := compare.LoadStatistics(legacyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Statistics, stats)
+
+	// Newer schema versions are rejected
+	future := filepath.Join(t.TempDir(), "future.json")
+	assert.NoError(t, os.WriteFile(future, []byte(`{"schemaVersion": 99}`), 0o644))
+	_, err = compare.LoadStatistics(future)
+	assert.ErrorContains(t, err, "unsupported schema version 99")
+
+	// The published schema is the one of the current types
+	published, err := os.ReadFile("../schema/report.schema.json")
+	assert.NoError(t, err)
+	assert.Equal(t, string(published), string(run("--json-schema")), "run make schema to update schema/report.schema.json")
+
+	// Every property is documented
+	var document struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"$defs"`
+	}
+	assert.NoError(t, json.Unmarshal(published, &document))
+	properties := []map[string]map[string]interface{}{document.Properties}
+	for _, def := range document.Defs {
+		properties = append(properties, def.Properties)
+	}
+	for _, props := range properties {
+		for name, property := range props {
+			assert.NotEmpty(t, property["description"], "property %s has no description", name)
+		}
+	}
+}
+
+func TestRepositoryHosts(t *testing.T) {
+	var fetched []string
+	fetcher := &mockFetcher{fetch: func(repo string) ([]types.PullRequest, error) {
+		fetched = append(fetched, repo)
+		return createTestPullRequests(), nil
+	}}
+	run := func(args ...string) (types.Report, error) {
+		buf := new(bytes.Buffer)
+		cmd := newTestApp(fetcher).Command()
+		cmd.SetOutput(buf)
+		cmd.SetArgs(append(args, "--format", "json"))
+		var report types.Report
+		if err := cmd.Execute(); err != nil {
+			return report, err
+		}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+		return report, nil
+	}
+
+	// Repositories of another host are passed to the fetcher with their host
+	report, err := run("GHE.example.com/owner/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ghe.example.com/owner/repo"}, fetched)
+	assert.Equal(t, []string{"ghe.example.com/owner/repo"}, report.Repositories)
+
+	// Repositories without a host are on the --hostname host
+	report, err = run("owner/repo", "--hostname", "ghe.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ghe.example.com/owner/repo"}, report.Repositories)
+
+	// Repository URLs are passed to the fetcher with their host
+	fetched = nil
+	_, err = run("https://github.com/owner/repo")
+	assert.NoError(t, err)
+	_, err = run("git@ghe.example.com:team/service.git")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"github.com/owner/repo", "ghe.example.com/team/service"}, fetched)
+
+	_, err = run("example.com/owner/repo/extra")
+	assert.ErrorContains(t, err, "Expected format: owner/repo, HOST/owner/repo or a repository URL")
+	_, err = run("https://github.com/owner")
+	assert.ErrorContains(t, err, "invalid repository URL")
+	_, err = run("owner/")
+	assert.ErrorContains(t, err, "invalid repository format")
+}
+
+// parseReport decodes a json report and returns its statistics
+func parseReport(t *testing.T, data []byte) types.Statistics {
+	t.Helper()
+	var report types.Report
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, types.SchemaVersion, report.SchemaVersion)
+	return report.Statistics
+}
+
+// recordingRenderer records the statistics it renders
+type recordingRenderer struct {
+	stats *types.Statistics
+}
+
+func (r recordingRenderer) Render(w io.Writer, report types.Report, opts render.Options) error {
+	*r.stats = report.Statistics
+	return nil
+}
+
+func TestAppRenderers(t *testing.T) {
+	fetcher := &mockFetcher{fetch: func(repo string) ([]types.PullRequest, error) {
+		return createTestPullRequests(), nil
+	}}
+
+	// Every app renders with its own formats and options
+	var table, markdown types.Statistics
+	first, second := newTestApp(fetcher), newTestApp(fetcher)
+	first.Renderers = render.NewRegistry(
+		render.Format{Name: "table", Renderer: recordingRenderer{&table}},
+		render.Format{Name: "markdown", Renderer: recordingRenderer{&markdown}},
+	)
+
+	cmd := first.Command()
+	cmd.SetArgs([]string{"owner/repo", "--format", "markdown"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "markdown", first.Options.Format)
+	assert.Equal(t, 2, markdown.OverallStats.Total)
+	assert.Zero(t, table.OverallStats.Total)
+
+	buf := new(bytes.Buffer)
+	cmd = second.Command()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "", second.Options.Format)
+	assert.Contains(t, buf.String(), "test_enhancement")
+
+	// Unknown formats are rejected before fetching
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		t.Fatal("FetchPullRequests should not be called")
+		return nil, nil
+	}
+	cmd, _ = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--format", "xml"})
+	assert.ErrorContains(t, cmd.Execute(), "Supported formats: table, json, csv, tsv, markdown, html, prom")
+
+	cmd, buf = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"--list-formats"})
+	assert.NoError(t, cmd.Execute())
+	for _, name := range []string{"table", "json", ".json", "markdown", ".md", "columns", "structured"} {
+		assert.Contains(t, buf.String(), name)
+	}
+}
+
+func TestRunCommandWithFilterPushdown(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		t.Fatal("FetchPullRequests should not be called")
+		return nil, nil
+	}
+
+	var qualifiers []string
+	fetcher.search = func(repo string, q []string) ([]types.PullRequest, error) {
+		assert.Equal(t, "owner/repo", repo)
+		qualifiers = q
+		return createAuthoredPullRequests(), nil
+	}
+
+	cmd, buf := setupTestCommand(fetcher)
+
+	// The --since window is evaluated locally only
+	cmd.SetArgs([]string{"owner/repo", "--format", "json", "--since", "2000-01-01", "--filter", `label:test_bug AND NOT author:renovate base:main created>=2024-01-01 title:"Test PullRequest"`})
+	assert.NoError(t, cmd.Execute())
+
+	assert.Equal(t, []string{"label:test_bug", "-author:renovate", "base:main", "created:>=2024-01-01"}, qualifiers)
+
+	// The expression is still applied to the search results
+	stats := parseReport(t, buf.Bytes())
+	assert.Equal(t, 2, stats.OverallStats.Total)
+}
+
+func TestServeFilter(t *testing.T) {
+	app := newTestApp(&mockFetcher{})
+	logs := new(bytes.Buffer)
+	app.Logger = slog.New(slog.NewTextHandler(logs, nil))
+	app.Options.Filter.Expr = "author:octocat"
+
+	prFilter, err := app.newServeFilter()
+	assert.NoError(t, err)
+	assert.Len(t, prFilter(createAuthoredPullRequests()), 1)
+
+	// A filter failing to parse on a refresh keeps the previous one
+	app.Options.Filter.Expr = "author:("
+	assert.Len(t, prFilter(createAuthoredPullRequests()), 1)
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "failed to parse filter")
+}
+
+func TestRunCommandWithSearchLimit(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.search = func(repo string, q []string) ([]types.PullRequest, error) {
+		return nil, &github.SearchLimitError{Total: 1500}
+	}
+	fetched := false
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		fetched = true
+		return createAuthoredPullRequests(), nil
+	}
+
+	// Without base terms, every pr is fetched and filtered locally
+	cmd, buf := setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--format", "json", "--filter", "label:test_bug"})
+	assert.NoError(t, cmd.Execute())
+	assert.True(t, fetched)
+	stats := parseReport(t, buf.Bytes())
+	assert.Equal(t, 2, stats.OverallStats.Total)
+
+	// Base terms cannot be evaluated locally
+	fetched = false
+	cmd, _ = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--format", "json", "--filter", "base:main"})
+	assert.ErrorContains(t, cmd.Execute(), "search matched 1500 prs")
+	assert.False(t, fetched)
+}
+
+func TestRunCommandWithSQLite(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		prs := createTestPullRequests()
+		for i := range prs {
+			prs[i].Number = i + 1
+		}
+		return prs, nil
+	}
+
+	submittedAt := time.Now()
+	fetcher.reviews = func(repo string, prs []types.PullRequest) (map[int][]types.Review, error) {
+		assert.Equal(t, "owner/repo", github.Qualify(repo, ""))
+		return map[int][]types.Review{
+			2: {{ID: 10, User: types.User{Login: "reviewer"}, State: "APPROVED", SubmittedAt: &submittedAt}},
+		}, nil
+	}
+
+	dbFile := filepath.Join(t.TempDir(), "stats.db")
+
+	// Running twice must upsert instead of failing on duplicate keys, also
+	// when the repository is given as a URL
+	for _, repository := range []string{"owner/repo", "https://github.com/owner/repo"} {
+		cmd, _ := setupTestCommand(fetcher)
+		cmd.SetArgs([]string{repository, "--format", "json", "--sqlite", dbFile})
+		assert.NoError(t, cmd.Execute())
+	}
+
+	db, err := sql.Open("sqlite", dbFile)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	count := func(query string) int {
+		var n int
+		assert.NoError(t, db.QueryRow(query).Scan(&n))
+		return n
+	}
+
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM pull_requests WHERE repository = 'owner/repo'"))
+	assert.Equal(t, 1, count("SELECT COUNT(DISTINCT repository) FROM runs"))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM pull_request_labels"))
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM reviews WHERE number = 2"))
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM pull_requests WHERE merged_at IS NULL AND closed_at IS NOT NULL"))
+
+	// Every run keeps its statistics
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM runs WHERE repository = 'owner/repo'"))
+	assert.Equal(t, 4, count("SELECT COUNT(*) FROM label_stats"))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM latest_label_stats"))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM overall_stats WHERE total = 2 AND merged = 0 AND p90_days_to_close IS NOT NULL"))
+	assert.Equal(t, 2, count("SELECT total FROM latest_overall_stats WHERE repository = 'owner/repo'"))
+}
+
+func TestRunCommandWithRawOutputFormat(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		prs := createTestPullRequests()
+		prs[1].Number = 2
+		prs[1].User = types.User{Login: "octocat"}
+		prs[1].PullRequest = &types.PullRequestRef{MergedAt: prs[1].ClosedAt}
+		return prs, nil
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "prs.csv")
+
+	cmd, _ := setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--format", "json", "-o", outputPath, "--output-format", "csv"})
+	assert.NoError(t, cmd.Execute())
+
+	file, err := os.Open(outputPath)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "Number", records[0][0])
+	assert.Equal(t, []string{"2", "Test PullRequest 2", "octocat", "closed"}, records[2][:4])
+	assert.NotEmpty(t, records[2][6], "merged date should be set")
+	assert.Equal(t, "test_enhancement", records[2][7])
+	assert.Equal(t, "1.00", records[2][8])
+
+	cmd, _ = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "-o", outputPath, "--output-format", "xml"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestRunCommandWithStatsFiles(t *testing.T) {
+	fetcher := &mockFetcher{fetch: func(repo string) ([]types.PullRequest, error) {
+		return createTestPullRequests(), nil
+	}}
+	dir := t.TempDir()
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	// The format of every file is inferred from its extension, json otherwise
+	cmd, _ := setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--columns", "label,open",
+		"--stats", filepath.Join(dir, "stats.csv"),
+		"--stats", filepath.Join(dir, "stats.md"),
+		"--stats", filepath.Join(dir, "stats.html"),
+		"--stats", filepath.Join(dir, "stats.prom"),
+		"--stats", filepath.Join(dir, "stats.out"),
+	})
+	assert.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "Label,Open\ntest_bug,1\ntest_enhancement,0\nTotal,1\n", read("stats.csv"))
+	assert.Contains(t, read("stats.md"), "| test_bug")
+	assert.Contains(t, read("stats.html"), "<th>Open</th>")
+	assert.Contains(t, read("stats.html"), "owner/repo")
+	assert.Contains(t, read("stats.html"), "<td>test_enhancement</td>")
+	assert.Contains(t, read("stats.prom"), `gh_pr_stats_pull_requests{repository="owner/repo",label="test_bug",state="open"} 1`)
+	assert.Contains(t, read("stats.prom"), `gh_pr_stats_overall_pull_requests{repository="owner/repo",state="closed"} 1`)
+
+	stats := parseReport(t, []byte(read("stats.out")))
+	assert.Equal(t, 2, stats.OverallStats.Total)
+
+	// --stats-format overrides the extension
+	cmd, _ = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--stats", filepath.Join(dir, "stats.txt"), "--stats-format", "tsv"})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, read("stats.txt"), "Label\tOpen")
+
+	// Unknown formats are rejected before fetching
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		t.Fatal("FetchPullRequests should not be called")
+		return nil, nil
+	}
+	cmd, _ = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/repo", "--stats", filepath.Join(dir, "stats.x"), "--stats-format", "xml"})
+	assert.ErrorContains(t, cmd.Execute(), "invalid stats format")
+}
+
+func TestCheckCommand(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return createTestPullRequests(), nil
+	}
+
+	tests := []struct {
+		name          string
+		rules         []string
+		expectError   bool
+		expectViolate bool
+	}{
+		{
+			name:  "All rules pass",
+			rules: []string{"overall.total > 2", "label[test_bug].open >= 2", "label[missing].open > 0"},
+		},
+		{
+			name:          "Threshold violated",
+			rules:         []string{"open_percentage > 15", "overall.median_days_to_close > 3"},
+			expectError:   true,
+			expectViolate: true,
+		},
+		{
+			name:        "Invalid rule",
+			rules:       []string{"label[bug].unknown > 1"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := newTestApp(fetcher).newCheckCommand()
+			cmd.SetOutput(buf)
+
+			args := []string{"owner/repo"}
+			for _, rule := range tt.rules {
+				args = append(args, "--fail-on", rule)
+			}
+			cmd.SetArgs(args)
+
+			err := cmd.Execute()
+			if !tt.expectError {
+				assert.NoError(t, err)
+				assert.Contains(t, buf.String(), "PASS")
+				return
+			}
+
+			assert.Error(t, err)
+			var violation *violationError
+			assert.Equal(t, tt.expectViolate, errors.As(err, &violation))
+			if tt.expectViolate {
+				assert.Equal(t, 1, violation.failed)
+				assert.Contains(t, buf.String(), "FAIL")
+			}
+		})
+	}
+}
+
+func TestCompareCommand(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) *time.Time {
+		t := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return []types.PullRequest{
+			{State: "closed", CreatedAt: daysAgo(40), ClosedAt: daysAgo(38), Labels: []types.Label{{Name: "bug"}}},
+			{State: "open", CreatedAt: daysAgo(10), Labels: []types.Label{{Name: "bug"}}},
+			{State: "open", CreatedAt: daysAgo(5), Labels: []types.Label{{Name: "bug"}}},
+			{State: "open", CreatedAt: daysAgo(100)},
+		}, nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newTestApp(fetcher).newCompareCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo", "--period", "30d", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+
+	var comparison compare.Comparison
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &comparison))
+	assert.Len(t, comparison.Labels, 1)
+	assert.Equal(t, "bug", comparison.Labels[0].Name)
+	assert.Equal(t, 1.0, comparison.Overall.Total.Base)
+	assert.Equal(t, 2.0, comparison.Overall.Total.Head)
+	assert.Equal(t, 1.0, comparison.Overall.Total.Delta)
+	assert.Equal(t, 100.0, *comparison.Overall.Total.PercentChange)
+	assert.Nil(t, comparison.Overall.Open.PercentChange, "percent change from zero is undefined")
+
+	// A previous window without prs has no open percentage instead of NaN
+	buf.Reset()
+	cmd = newTestApp(fetcher).newCompareCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo", "--period", "20d", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+	comparison = compare.Comparison{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &comparison))
+	assert.Equal(t, 0.0, comparison.Overall.Total.Base)
+	assert.Equal(t, 0.0, comparison.Overall.OpenPercentage.Base)
+	assert.Equal(t, 100.0, comparison.Overall.OpenPercentage.Head)
+
+	cmd = newTestApp(fetcher).newCompareCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestAgingCommand(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) *time.Time {
+		t := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return []types.PullRequest{
+			{Number: 1, State: "open", CreatedAt: daysAgo(120), UpdatedAt: daysAgo(60), Labels: []types.Label{{Name: "bug"}}},
+			{Number: 2, State: "open", CreatedAt: daysAgo(3), UpdatedAt: daysAgo(1), Labels: []types.Label{{Name: "bug"}}},
+			{Number: 3, State: "open", CreatedAt: daysAgo(45), UpdatedAt: daysAgo(40)},
+			{Number: 4, State: "closed", CreatedAt: daysAgo(200), ClosedAt: daysAgo(100)},
+		}, nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newTestApp(fetcher).newAgingCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo", "--stale-after", "30d", "--oldest", "2", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+
+	var report types.AgingReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+
+	assert.Equal(t, 3, report.OverallAging.Open)
+	assert.Equal(t, 2, report.OverallAging.Stale)
+	assert.Equal(t, []types.AgeBucket{
+		{Name: "<1d", Count: 0},
+		{Name: "1-7d", Count: 1},
+		{Name: "7-30d", Count: 0},
+		{Name: "30-90d", Count: 1},
+		{Name: ">90d", Count: 1},
+	}, report.OverallAging.Buckets)
+
+	assert.Equal(t, "bug", report.LabelAging[0].Name)
+	assert.Equal(t, 2, report.LabelAging[0].Open)
+	assert.Equal(t, 1, report.LabelAging[0].Stale)
+
+	assert.Len(t, report.Oldest, 2)
+	assert.Equal(t, 1, report.Oldest[0].Number)
+	assert.Equal(t, 3, report.Oldest[1].Number)
+	assert.True(t, report.Oldest[0].Stale)
+
+	// Staleness is measured in working days like the ages
+	buf = new(bytes.Buffer)
+	root := newTestApp(fetcher).Command()
+	root.SetOutput(buf)
+	root.SetArgs([]string{"aging", "owner/repo", "--stale-after", "30d", "--format", "json", "--business-time"})
+	assert.NoError(t, root.Execute())
+	report = types.AgingReport{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 1, report.OverallAging.Stale)
+	assert.True(t, report.Oldest[0].Stale)
+	assert.False(t, report.Oldest[1].Stale)
+
+	// Formats the command cannot write are rejected before fetching
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		t.Fatal("FetchPullRequests should not be called")
+		return nil, nil
+	}
+	cmd = newTestApp(fetcher).newAgingCommand()
+	cmd.SetOutput(new(bytes.Buffer))
+	cmd.SetArgs([]string{"owner/repo", "--format", "csv"})
+	assert.ErrorContains(t, cmd.Execute(), `unsupported format "csv". Supported formats: table, json`)
+}
+
+func TestLabelsMatrixCommand(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return createMixedLabelPullRequests(), nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newTestApp(fetcher).newLabelsCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"matrix", "owner/repo", "--top", "3", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+
+	var matrix types.LabelMatrix
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &matrix))
+
+	assert.Equal(t, 5, matrix.PullRequests)
+	assert.Equal(t, []types.LabelCount{
+		{Name: "area/frontend", Total: 2, Exclusive: 0},
+		{Name: "Bug", Total: 1, Exclusive: 0},
+		{Name: "area/backend", Total: 1, Exclusive: 0},
+	}, matrix.Labels)
+	assert.Equal(t, [][]int{{2, 1, 0}, {1, 1, 0}, {0, 0, 1}}, matrix.Matrix)
+	assert.Equal(t, []types.LabelPair{{Labels: [2]string{"area/frontend", "Bug"}, Count: 1}}, matrix.Pairs)
+
+	// The top option of the configuration files limits the label rows only
+	setupConfigFiles(t, "", "top: 1\n")
+	buf.Reset()
+	cmd = newTestApp(fetcher).newLabelsCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"matrix", "owner/repo", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+	matrix = types.LabelMatrix{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &matrix))
+	assert.Len(t, matrix.Labels, 7)
+}
+
+func TestSizeCommand(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) *time.Time {
+		t := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+	merged := func(days int) *types.PullRequestRef {
+		return &types.PullRequestRef{MergedAt: daysAgo(days)}
+	}
+
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return []types.PullRequest{
+			{Number: 1, State: "closed", CreatedAt: daysAgo(10), ClosedAt: daysAgo(9), PullRequest: merged(9), Labels: []types.Label{{Name: "bug"}}},
+			{Number: 2, State: "closed", CreatedAt: daysAgo(10), ClosedAt: daysAgo(7), PullRequest: merged(7), Labels: []types.Label{{Name: "bug"}}},
+			{Number: 3, State: "closed", CreatedAt: daysAgo(10), ClosedAt: daysAgo(2)},
+		}, nil
+	}
+
+	fetcher.sizes = func(repo string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+		assert.Len(t, prs, 3)
+		return map[int]types.PullRequestSize{
+			1: {Additions: 3, Deletions: 2, ChangedFiles: 1, Commits: 1},
+			2: {Additions: 40, Deletions: 10, ChangedFiles: 4, Commits: 3},
+			3: {Additions: 2000, ChangedFiles: 30, Commits: 12},
+		}, nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newTestApp(fetcher).newSizeCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+
+	var report types.SizeReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+
+	assert.Len(t, report.SizeBuckets, 5)
+	assert.Equal(t, types.SizeBucketStat{Name: "XS", MaxLines: 10, Total: 1, Merged: 1, MergeRate: 100, MedianDaysToMerge: 1}, report.SizeBuckets[0])
+	assert.Equal(t, 1, report.SizeBuckets[1].Total)
+	assert.Equal(t, 3.0, report.SizeBuckets[1].MedianDaysToMerge)
+	assert.Equal(t, types.SizeBucketStat{Name: "XL", Total: 1}, report.SizeBuckets[4])
+
+	assert.Equal(t, "bug", report.LabelSizes[0].Name)
+	assert.Equal(t, 27.5, report.LabelSizes[0].MedianLines)
+
+	cmd = newTestApp(fetcher).newSizeCommand()
+	cmd.SetArgs([]string{"owner/repo", "--size-thresholds", "10,5,20,30"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestInsightsCommand(t *testing.T) {
+	now := time.Now()
+	var prs []types.PullRequest
+	sizes := make(map[int]types.PullRequestSize)
+	for i := 1; i <= 4; i++ {
+		createdAt := now.Add(-time.Duration(10*i) * 24 * time.Hour)
+		mergedAt := createdAt.Add(time.Duration(i) * 24 * time.Hour)
+		prs = append(prs, types.PullRequest{
+			Number:      i,
+			State:       "closed",
+			CreatedAt:   &createdAt,
+			ClosedAt:    &mergedAt,
+			PullRequest: &types.PullRequestRef{MergedAt: &mergedAt},
+		})
+		// Larger prs take longer to merge, more commits are faster
+		sizes[i] = types.PullRequestSize{Additions: 100 * i, ChangedFiles: 1, Commits: 10 - i}
+	}
+	prs = append(prs, types.PullRequest{Number: 5, State: "open", CreatedAt: &now})
+
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return prs, nil
+	}
+
+	fetcher.sizes = func(repo string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+		assert.Len(t, prs, 4, "only merged prs should be fetched")
+		return sizes, nil
+	}
+
+	fetcher.reviews = func(repo string, prs []types.PullRequest) (map[int][]types.Review, error) {
+		return map[int][]types.Review{}, nil
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newTestApp(fetcher).newInsightsCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"owner/repo", "--format", "json"})
+	assert.NoError(t, cmd.Execute())
+
+	var report types.InsightsReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 4, report.MergedPullRequests)
+
+	correlations := make(map[string]types.Correlation)
+	for _, c := range report.Correlations {
+		correlations[c.Attribute] = c
+	}
+	assert.InDelta(t, 1.0, correlations["changed lines"].Coefficient, 1e-9)
+	assert.InDelta(t, -1.0, correlations["commits"].Coefficient, 1e-9)
+	assert.Equal(t, "strong", correlations["changed lines"].Strength)
+	assert.Equal(t, "n/a", correlations["changed files"].Strength, "constant values have no correlation")
+	assert.Equal(t, "n/a", correlations["reviewers"].Strength)
+}
+
+func TestInsightsCommandTimezone(t *testing.T) {
+	var prs []types.PullRequest
+	for i := 1; i <= 4; i++ {
+		// Created at 13:00 to 16:00 UTC, the slower prs created later
+		createdAt := time.Date(2024, 3, 4, 12+i, 0, 0, 0, time.UTC)
+		mergedAt := createdAt.Add(time.Duration(i) * 24 * time.Hour)
+		prs = append(prs, types.PullRequest{
+			Number:      i,
+			State:       "closed",
+			CreatedAt:   &createdAt,
+			ClosedAt:    &mergedAt,
+			PullRequest: &types.PullRequestRef{MergedAt: &mergedAt},
+		})
+	}
+
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		return prs, nil
+	}
+	fetcher.sizes = func(repo string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+		return map[int]types.PullRequestSize{}, nil
+	}
+	fetcher.reviews = func(repo string, prs []types.PullRequest) (map[int][]types.Review, error) {
+		return map[int][]types.Review{}, nil
+	}
+
+	tests := []struct {
+		timezone string
+		hour     float64
+		weekday  string
+	}{
+		{timezone: "UTC", hour: 1, weekday: "n/a"},
+		// 22:00 and 23:00 on Monday, 00:00 and 01:00 on Tuesday in Tokyo
+		{timezone: "Asia/Tokyo", hour: -0.6, weekday: "strong"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			root := newTestApp(fetcher).Command()
+			root.SetOutput(buf)
+			root.SetArgs([]string{"insights", "owner/repo", "--format", "json", "--timezone", tt.timezone})
+			assert.NoError(t, root.Execute())
+
+			var report types.InsightsReport
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+			correlations := make(map[string]types.Correlation)
+			for _, c := range report.Correlations {
+				correlations[c.Attribute] = c
+			}
+			assert.InDelta(t, tt.hour, correlations["created hour"].Coefficient, 1e-9)
+			assert.Equal(t, tt.weekday, correlations["created weekday"].Strength)
+		})
+	}
+}
+
+// Helper to run a test in a git repository with configuration files
+func setupConfigFiles(t *testing.T, userConfig, repoConfig string) {
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	if userConfig != "" {
+		assert.NoError(t, os.MkdirAll(filepath.Join(userDir, "gh-pr-stats"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(userDir, "gh-pr-stats", "config.yml"), []byte(userConfig), 0o644))
+	}
+
+	repoDir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(repoDir, ".git"), 0o755))
+	assert.NoError(t, os.Mkdir(filepath.Join(repoDir, "sub"), 0o755))
+	if repoConfig != "" {
+		assert.NoError(t, os.WriteFile(filepath.Join(repoDir, ".gh-pr-stats.yml"), []byte(repoConfig), 0o644))
+	}
+
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(filepath.Join(repoDir, "sub")))
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+	})
+}
+
+func TestConfigFiles(t *testing.T) {
+	setupConfigFiles(t, `
+format: csv
+columns: [label, total]
+repositories: [owner/user]
+`, `
+format: json
+exclude-bots: true
+repositories: [owner/repo]
+labels:
+  aliases:
+    bug: [test_bug]
+`)
+
+	var fetched string
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		fetched = repo
+		return createAuthoredPullRequests(), nil
+	}
+
+	// The repository configuration takes precedence over the user configuration
+	cmd, buf := setupTestCommand(fetcher)
+	cmd.SetArgs([]string{})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "owner/repo", fetched)
+
+	stats := parseReport(t, buf.Bytes())
+	assert.Equal(t, 2, stats.OverallStats.Total)
+	assert.Equal(t, "bug", stats.LabelStats[0].Name)
+
+	// Flags take precedence over the configuration files
+	cmd, buf = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"owner/other", "--format", "csv"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, "owner/other", fetched)
+
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Label", "Total"}, records[0])
+	assert.Len(t, records, 4)
+}
+
+func TestConcurrency(t *testing.T) {
+	fetcher := &mockFetcher{fetch: func(repo string) ([]types.PullRequest, error) {
+		return createTestPullRequests(), nil
+	}}
+	run := func(args ...string) *App {
+		app := newTestApp(fetcher)
+		cmd := app.Command()
+		cmd.SetOutput(io.Discard)
+		cmd.SetArgs(append([]string{"owner/repo", "--format", "json"}, args...))
+		assert.NoError(t, cmd.Execute())
+		return app
+	}
+
+	assert.Equal(t, defaultConcurrency, run().Options.Concurrency)
+	assert.Equal(t, 2, run("--concurrency", "2").Options.Concurrency)
+
+	setupConfigFiles(t, "", "concurrency: 8\n")
+	assert.Equal(t, 8, run().Options.Concurrency)
+
+	app := NewApp()
+	app.Options.Concurrency = 0
+	_, err := app.fetcher()
+	assert.ErrorContains(t, err, "--concurrency must be positive")
+}
+
+func TestConfigCommands(t *testing.T) {
+	setupConfigFiles(t, "format: csv\n", "top: 5\ngroup-by: author\n")
+
+	buf := new(bytes.Buffer)
+	cmd := newTestApp(&mockFetcher{}).newConfigCommand()
+	cmd.SetOutput(buf)
+	cmd.SetArgs([]string{"show"})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "config.yml")
+	assert.Contains(t, buf.String(), ".gh-pr-stats.yml")
+	assert.Contains(t, buf.String(), "group-by")
+
+	cmd.SetArgs([]string{"validate"})
+	assert.NoError(t, cmd.Execute())
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yml")
+	assert.NoError(t, os.WriteFile(invalid, []byte(`
+format: xml
+filter: "label:bug AND ("
+thresholds:
+  stale-after: soon
+  fail-on: ["open >"]
+profiles:
+  weekly:
+    since: last week
+`), 0o644))
+
+	buf.Reset()
+	cmd.SetArgs([]string{"validate", invalid})
+	assert.Error(t, cmd.Execute())
+	for _, option := range []string{"format", "filter", "thresholds.stale-after", "thresholds.fail-on", "profiles.weekly: since"} {
+		assert.Contains(t, buf.String(), option+":")
+	}
+
+	assert.NoError(t, os.WriteFile(invalid, []byte("colums: [label]\n"), 0o644))
+	cmd.SetArgs([]string{"validate", invalid})
+	assert.ErrorContains(t, cmd.Execute(), "field colums not found")
+}
+
+func TestConfigProfiles(t *testing.T) {
+	setupConfigFiles(t, `
+profiles:
+  weekly:
+    format: csv
+`, `
+format: csv
+profiles:
+  weekly:
+    repositories: [owner/weekly]
+    since: 7d
+    group-by: author
+    format: json
+  sla:
+    thresholds:
+      fail-on: ["overall.median_days_to_close > 3"]
+`)
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	fetcher := &mockFetcher{}
+	// The since window is applied locally instead of narrowing the search
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		assert.Equal(t, "owner/weekly", repo)
+		prs := createAuthoredPullRequests()
+		prs[3].CreatedAt = &old
+		return prs, nil
+	}
+
+	cmd, buf := setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"--profile", "weekly"})
+	assert.NoError(t, cmd.Execute())
+
+	stats := parseReport(t, buf.Bytes())
+	assert.Equal(t, 3, stats.OverallStats.Total)
+	assert.Equal(t, "dependabot[bot]", stats.LabelStats[0].Name)
+
+	cmd, _ = setupTestCommand(fetcher)
+	cmd.SetArgs([]string{"--profile", "monthly"})
+	assert.ErrorContains(t, cmd.Execute(), "Available profiles: sla, weekly")
+}
+
+func TestPipelineCommands(t *testing.T) {
+	fetcher := &mockFetcher{}
+	fetcher.fetch = func(repo string) ([]types.PullRequest, error) {
+		assert.Equal(t, "owner/repo", repo)
+		return createAuthoredPullRequests(), nil
+	}
+
+	dir := t.TempDir()
+	rawFile := filepath.Join(dir, "prs.jsonl")
+	statsPath := filepath.Join(dir, "stats.json")
+
+	run := func(cmd *cobra.Command, args ...string) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		cmd.SetOutput(buf)
+		cmd.SetArgs(args)
+		assert.NoError(t, cmd.Execute())
+		return buf
+	}
+
+	// Fetch to a file, analyze it and render the statistics
+	run(newTestApp(fetcher).newFetchCommand(), "owner/repo", "--output", rawFile, "--output-format", "jsonl")
+	app := newTestApp(fetcher)
+	analyzeCmd := app.newAnalyzeCommand()
+	app.addFilterFlags(analyzeCmd.Flags())
+	app.addLabelFlags(analyzeCmd.Flags())
+	run(analyzeCmd, "--input", rawFile, "--output", statsPath, "--exclude-bots")
+	buf := run(newTestApp(fetcher).newReportCommand(), "--input", statsPath, "--format", "csv", "--columns", "label,total")
+
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Label", "Total"},
+		{"test_bug", "1"},
+		{"test_enhancement", "1"},
+		{"Total", "2"},
+	}, records)
+
+	// Fetch to the cache and analyze the cached data of the repository
+	run(newTestApp(fetcher).newFetchCommand(), "owner/repo")
+	buf = run(newTestApp(fetcher).newAnalyzeCommand(), "owner/repo", "--group-by", "author-type")
+
+	stats := parseReport(t, buf.Bytes())
+	assert.Equal(t, 4, stats.OverallStats.Total)
+	assert.Equal(t, "bot", stats.LabelStats[0].Name)
+
+	// Statistics are read from stdin by default
+	reportCmd := newTestApp(fetcher).newReportCommand()
+	reportCmd.SetIn(bytes.NewReader(buf.Bytes()))
+	buf = run(reportCmd, "--format", "json")
+	assert.Contains(t, buf.String(), `"human"`)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/compare"
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// CompareOptions selects the compared statistics
+type CompareOptions struct {
+	// Period is the length of the compared windows
+	Period string
+	// To is the window compared against
+	To string
+	// Base and Head are the compared statistics files
+	Base string
+	Head string
+}
+
+func (a *App) newCompareCommand() *cobra.Command {
+	compareCmd := &cobra.Command{
+		Use:   "compare [repository]",
+		Short: "Compare pr statistics of two periods or two saved stats files",
+		Long: `Compare the pr statistics of two time windows, or of two files saved with --stats,
+and show the absolute and percentage change per label and overall.
+
+Examples:
+  # Prs created in the last 30 days against the 30 days before
+  gh pr-stats compare owner/repo --period 30d --compare-to previous
+
+  # Two saved statistics files
+  gh pr-stats compare --base last-month.json --head stats.json --format csv`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runCompare,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	compareCmd.Flags().StringVar(&a.Options.Compare.Period, "period", "", "Length of the compared windows, e.g. 30d, 2w or 12h")
+	compareCmd.Flags().StringVar(&a.Options.Compare.To, "compare-to", "previous", "Window to compare against: previous")
+	compareCmd.Flags().StringVar(&a.Options.Compare.Base, "base", "", "Statistics file to compare from")
+	compareCmd.Flags().StringVar(&a.Options.Compare.Head, "head", "", "Statistics file to compare to")
+	compareCmd.Flags().StringVarP(&a.Options.Format, "format", "f", "", "Output format: table (default), json, csv, or tsv")
+	compareCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return compareCmd
+}
+
+func (a *App) runCompare(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+	format, err := a.commandFormat(render.Table.Name, render.JSON.Name, render.CSV.Name, render.TSV.Name)
+	if err != nil {
+		return err
+	}
+
+	opts := a.Options.Compare
+	var base, head types.Statistics
+	switch {
+	case opts.Base != "" || opts.Head != "":
+		if opts.Base == "" || opts.Head == "" || len(args) > 0 || opts.Period != "" {
+			return fmt.Errorf("--base and --head must be given together and cannot be combined with a repository or --period")
+		}
+
+		var err error
+		if base, err = compare.LoadStatistics(opts.Base); err != nil {
+			return err
+		}
+		if head, err = compare.LoadStatistics(opts.Head); err != nil {
+			return err
+		}
+	case opts.Period != "":
+		period, err := utils.ParsePeriod(opts.Period)
+		if err != nil {
+			return err
+		}
+		if opts.To != "previous" {
+			return fmt.Errorf("invalid --compare-to %q. Supported: previous", opts.To)
+		}
+
+		repository, err := a.repositoryArg(args)
+		if err != nil {
+			return err
+		}
+
+		prs, err := a.fetchPullRequests(repository)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		base = stats.CalculateStatistics(compare.CreatedBetween(prs, now.Add(-2*period), now.Add(-period)), a.days)
+		head = stats.CalculateStatistics(compare.CreatedBetween(prs, now.Add(-period), now), a.days)
+	default:
+		return fmt.Errorf("either --period or --base and --head must be given")
+	}
+
+	comparison := compare.Compare(base, head)
+
+	switch format {
+	case render.JSON.Name:
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(comparison)
+	case render.CSV.Name:
+		return utils.WriteDelimitedComparison(cmd, comparison, ',')
+	case render.TSV.Name:
+		return utils.WriteDelimitedComparison(cmd, comparison, '\t')
+	case render.Table.Name:
+		utils.PrintComparison(cmd, comparison)
+	}
+
+	return nil
+}
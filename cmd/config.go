@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/shufo/gh-pr-stats/internal/config"
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// loadConfig loads the configuration files and the selected profile, in
+// order of increasing precedence
+func (a *App) loadConfig() ([]*config.File, error) {
+	files, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if a.Options.Profile == "" {
+		return files, nil
+	}
+
+	selected, err := config.Profile(files, a.Options.Profile)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, selected), nil
+}
+
+// ignoreConfigAnnotation marks the flags that share the name of an option
+// of the configuration files without sharing its meaning
+const ignoreConfigAnnotation = "gh-pr-stats/ignore-config"
+
+// ignoreConfig keeps the configuration files from setting the named flag
+func ignoreConfig(flags *pflag.FlagSet, name string) {
+	_ = flags.SetAnnotation(name, ignoreConfigAnnotation, []string{"true"})
+}
+
+// applyConfig sets the flags of cmd that were not given on the command line
+// from the configuration files. Flags take precedence over the selected
+// profile, the repository configuration and the user configuration, in
+// that order.
+func (a *App) applyConfig(cmd *cobra.Command) error {
+	files, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	a.configRepositories = config.Repositories(files)
+
+	flags := cmd.Flags()
+	for _, setting := range config.Resolve(files) {
+		flag := flags.Lookup(setting.Flag)
+		if flag == nil || flag.Changed || flag.Annotations[ignoreConfigAnnotation] != nil {
+			continue
+		}
+		for _, value := range setting.Values {
+			if err := flags.Set(setting.Flag, value); err != nil {
+				return fmt.Errorf("invalid %s in %s: %v", setting.Flag, setting.Source, err)
+			}
+		}
+	}
+	return nil
+}
+
+// repositoryArg returns the repository argument, the repository of the
+// configuration files, or the repository of the git remotes of the current
+// directory, in the HOST/owner/repo format when it has a host
+func (a *App) repositoryArg(args []string) (string, error) {
+	var repository string
+	if len(args) > 0 {
+		repository = args[0]
+	} else {
+		switch len(a.configRepositories) {
+		case 0:
+			current, err := github.CurrentRepository(a.Options.Remote)
+			if err != nil {
+				return "", fmt.Errorf("failed to get current repository: %w", err)
+			}
+			return current.String(), nil
+		case 1:
+			repository = a.configRepositories[0]
+		default:
+			return "", fmt.Errorf("%d repositories are configured: pass the repository to analyze as an argument", len(a.configRepositories))
+		}
+	}
+
+	repo, err := github.ParseRepository(repository)
+	if err != nil {
+		return "", err
+	}
+	return repo.String(), nil
+}
+
+func (a *App) newConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show and validate the configuration files",
+		Long: fmt.Sprintf(`Options are read from the user configuration file %s
+and the repository configuration file %s in the current directory or its closest parent.
+Command line flags take precedence over the profile selected with --profile, the
+repository configuration and the user configuration, in that order.`, config.UserPath(), config.RepoFileName),
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective configuration and where each option comes from",
+		Long: `Show the effective configuration and where each option comes from.
+Pass --profile to include the options of a profile.`,
+		Args:          cobra.NoArgs,
+		RunE:          a.runConfigShow,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	validateCmd := &cobra.Command{
+		Use:   "validate [file...]",
+		Short: "Validate configuration files",
+		Long: `Validate the given configuration files, or the user and repository configuration files.
+
+Examples:
+  gh pr-stats config validate
+  gh pr-stats config validate .gh-pr-stats.yml`,
+		RunE:          runConfigValidate,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	configCmd.AddCommand(showCmd, validateCmd)
+	return configCmd
+}
+
+func (a *App) runConfigShow(cmd *cobra.Command, args []string) error {
+	files, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if len(files) == 0 {
+		fmt.Fprintln(out, "No configuration files found")
+		return nil
+	}
+	for _, file := range files {
+		fmt.Fprintf(out, "Loaded %s\n", file.Path)
+	}
+	if names := config.ProfileNames(files); len(names) > 0 {
+		fmt.Fprintf(out, "Profiles: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintln(out)
+
+	t := table.NewWriter()
+	t.SetOutputMirror(out)
+	t.SetStyle(table.StyleRounded)
+	t.Style().Format.Header = text.FormatTitle
+	t.AppendHeader(table.Row{"Option", "Value", "Source"})
+
+	if repositories := config.Repositories(files); len(repositories) > 0 {
+		source := ""
+		for _, file := range files {
+			if len(file.Config.Repositories) > 0 {
+				source = file.Path
+			}
+		}
+		t.AppendRow(table.Row{"repositories", strings.Join(repositories, ", "), source})
+	}
+	for _, setting := range config.Resolve(files) {
+		t.AppendRow(table.Row{setting.Flag, strings.Join(setting.Values, "; "), setting.Source})
+	}
+
+	t.Render()
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	var files []*config.File
+	if len(args) == 0 {
+		loaded, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if len(loaded) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No configuration files found")
+			return nil
+		}
+		files = loaded
+	} else {
+		for _, path := range args {
+			file, err := config.LoadFile(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, file)
+		}
+	}
+
+	invalid := 0
+	for _, file := range files {
+		errs := file.Config.Validate()
+		if len(errs) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: ok\n", file.Path)
+			continue
+		}
+		for _, err := range errs {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %v\n", file.Path, err)
+		}
+		invalid += len(errs)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid option(s)", invalid)
+	}
+	return nil
+}
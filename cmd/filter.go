@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/filter"
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/spf13/pflag"
+)
+
+// FilterOptions selects the analyzed prs
+type FilterOptions struct {
+	Authors filter.AuthorOptions
+	// Expr is the filter expression
+	Expr string
+	// NoPushdown evaluates the expression locally instead of narrowing the search
+	NoPushdown bool
+	// Since is the period or date the prs were created since
+	Since string
+}
+
+func (a *App) addFilterFlags(flags *pflag.FlagSet) {
+	opts := &a.Options.Filter
+	flags.StringSliceVar(&opts.Authors.Exclude, "exclude-author", nil, "Exclude prs by these authors (repeatable or comma separated)")
+	flags.BoolVar(&opts.Authors.ExcludeBots, "exclude-bots", false, "Exclude prs authored by bots such as dependabot[bot]")
+	flags.BoolVar(&opts.Authors.OnlyBots, "only-bots", false, "Only include prs authored by bots")
+	flags.StringVar(&opts.Expr, "filter", "", `Filter expression, e.g. 'label:bug AND NOT author:renovate[bot] AND created>=2024-01-01'`)
+	flags.BoolVar(&opts.NoPushdown, "no-pushdown", false, "Evaluate --filter locally instead of narrowing the GitHub search query")
+	flags.StringVar(&opts.Since, "since", "", "Only include prs created within this period, e.g. 7d, or since this date, e.g. 2024-01-01")
+}
+
+// parseFilter parses the --filter expression combined with the --since
+// window, returning nil when neither is set. The window is evaluated
+// locally so that it never pushes the fetch into the capped search API.
+func (a *App) parseFilter() (filter.Expr, error) {
+	opts := a.Options.Filter
+	var expr filter.Expr
+	if opts.Expr != "" {
+		var err error
+		if expr, err = filter.Parse(opts.Expr); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Since != "" {
+		start, err := utils.ParseSince(opts.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		created, err := filter.Parse("created>=" + start.UTC().Format(time.RFC3339))
+		if err != nil {
+			return nil, err
+		}
+		var window filter.Expr = filter.LocalOnly{Expr: created}
+		if expr != nil {
+			window = filter.And{Left: window, Right: expr}
+		}
+		expr = window
+	}
+
+	if expr == nil {
+		return nil, nil
+	}
+	if err := filter.ValidatePushdown(expr); err != nil {
+		return nil, err
+	}
+	if opts.NoPushdown && filter.RequiresSearch(expr) {
+		return nil, fmt.Errorf("invalid filter: base terms require the search query and cannot be used with --no-pushdown")
+	}
+	return expr, nil
+}
+
+// filterPullRequests applies the filter flags to the prs and normalizes
+// the labels of the remaining prs
+func (a *App) filterPullRequests(prs []types.PullRequest, expr filter.Expr) []types.PullRequest {
+	prs = filter.Apply(filter.Authors(prs, a.Options.Filter.Authors), filter.Local(expr))
+	return a.labels.Apply(prs)
+}
+
+// parseFilterFlags validates the author flags and parses the filter expression
+func (a *App) parseFilterFlags() (filter.Expr, error) {
+	if err := a.Options.Filter.Authors.Validate(); err != nil {
+		return nil, err
+	}
+	return a.parseFilter()
+}
+
+// downloadPullRequests fetches the prs of the repository. Terms of the
+// filter expression narrow the search query, unless the search matches more
+// prs than the search API returns: the prs are then fetched in full and
+// filtered locally. Base terms can only be evaluated by the search query.
+func (a *App) downloadPullRequests(repository string, expr filter.Expr) ([]types.PullRequest, error) {
+	fetcher, err := a.fetcher()
+	if err != nil {
+		return nil, err
+	}
+	qualifiers := filter.SearchQualifiers(expr)
+	if len(qualifiers) == 0 || a.Options.Filter.NoPushdown {
+		return fetcher.FetchPullRequests(repository)
+	}
+
+	prs, err := fetcher.SearchPullRequests(repository, qualifiers)
+	var limitErr *github.SearchLimitError
+	if !errors.As(err, &limitErr) {
+		return prs, err
+	}
+	if filter.RequiresSearch(expr) {
+		return nil, fmt.Errorf("failed to search prs: %v. Narrow --filter, e.g. with a created>= term, to use base terms", err)
+	}
+	a.Logger.Debug(fmt.Sprintf("%v, fetching every pr instead", err))
+	return fetcher.FetchPullRequests(repository)
+}
+
+// fetchPullRequests fetches the prs of the repository and applies the filter flags
+func (a *App) fetchPullRequests(repository string) ([]types.PullRequest, error) {
+	expr, err := a.parseFilterFlags()
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := a.downloadPullRequests(repository, expr)
+	if err != nil {
+		return nil, err
+	}
+	return a.filterPullRequests(prs, expr), nil
+}
+
+// newServeFilter returns the filter flags as a function over the synced prs
+func (a *App) newServeFilter() (func([]types.PullRequest) []types.PullRequest, error) {
+	expr, err := a.parseFilterFlags()
+	if err != nil {
+		return nil, err
+	}
+	if filter.RequiresSearch(expr) {
+		return nil, fmt.Errorf("invalid filter: base terms are not supported by serve")
+	}
+
+	// The server refreshes one repository at a time, so the last good
+	// expression is never accessed concurrently
+	last := expr
+	return func(prs []types.PullRequest) []types.PullRequest {
+		// Parse again so a --since window moves with every refresh
+		current, err := a.parseFilter()
+		if err != nil {
+			a.Logger.Warn(fmt.Sprintf("failed to parse filter, keeping the previous one: %v", err))
+			current = last
+		}
+		last = current
+		return a.filterPullRequests(prs, current)
+	}, nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+func (a *App) newInsightsCommand() *cobra.Command {
+	insightsCmd := &cobra.Command{
+		Use:   "insights [repository]",
+		Short: "Correlate pr attributes with time to merge",
+		Long: `Compute the Spearman rank correlation of time to merge against the size, number of
+reviewers, number of commits, number of labels and creation hour and weekday of merged prs,
+ranked by strength.
+
+This fetches the details and reviews of every merged pr and can take a while on large repositories.
+
+Examples:
+  gh pr-stats insights owner/repo
+  gh pr-stats insights owner/repo --format json`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runInsights,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	insightsCmd.Flags().StringVarP(&a.Options.Format, "format", "f", "", "Output format: table (default) or json")
+	insightsCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return insightsCmd
+}
+
+func (a *App) runInsights(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+	format, err := a.commandFormat(render.Table.Name, render.JSON.Name)
+	if err != nil {
+		return err
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+
+	prs, err := a.fetchPullRequests(repository)
+	if err != nil {
+		return err
+	}
+
+	// Only merged prs have a time to merge, so skip fetching the details of the others
+	var merged []types.PullRequest
+	for _, pr := range prs {
+		if pr.MergedAt() != nil {
+			merged = append(merged, pr)
+		}
+	}
+
+	if merged, err = a.withSizes(repository, merged); err != nil {
+		return err
+	}
+	fetcher, err := a.fetcher()
+	if err != nil {
+		return err
+	}
+	reviews, err := fetcher.FetchReviews(repository, merged)
+	if err != nil {
+		return err
+	}
+
+	report := stats.CalculateInsights(merged, reviews, a.days, a.location)
+
+	switch format {
+	case render.JSON.Name:
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case render.Table.Name:
+		utils.PrintInsightsReport(cmd, report)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/shufo/gh-pr-stats/internal/labels"
+	"github.com/spf13/pflag"
+)
+
+// LabelOptions normalizes the label names before aggregation
+type LabelOptions struct {
+	IgnoreCase bool
+	// Aliases merge labels into a canonical label, e.g. "bug=type: bug,kind/bug"
+	Aliases []string
+	Prefix  string
+	// Include and Exclude are regular expressions selecting labels
+	Include []string
+	Exclude []string
+}
+
+func (a *App) addLabelFlags(flags *pflag.FlagSet) {
+	opts := &a.Options.Labels
+	flags.BoolVar(&opts.IgnoreCase, "label-ignore-case", false, "Merge labels that only differ in case, e.g. bug and Bug")
+	flags.StringArrayVar(&opts.Aliases, "label-alias", nil, `Merge labels into a canonical label, e.g. "bug=type: bug,kind/bug" (repeatable)`)
+	flags.StringVar(&opts.Prefix, "label-prefix", "", "Only aggregate labels with this prefix, e.g. area/")
+	flags.StringArrayVar(&opts.Include, "include-labels", nil, "Only aggregate labels matching this regular expression (repeatable)")
+	flags.StringArrayVar(&opts.Exclude, "exclude-labels", nil, "Do not aggregate labels matching this regular expression (repeatable)")
+}
+
+// setupLabels compiles the label flags into the label normalizer
+func (a *App) setupLabels() error {
+	opts := a.Options.Labels
+	aliases, err := labels.ParseAliases(opts.Aliases)
+	if err != nil {
+		return err
+	}
+
+	a.labels, err = labels.New(labels.Options{
+		IgnoreCase: opts.IgnoreCase,
+		Aliases:    aliases,
+		Prefix:     opts.Prefix,
+		Include:    opts.Include,
+		Exclude:    opts.Exclude,
+	})
+	return err
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// MatrixOptions configures the label matrix
+type MatrixOptions struct {
+	// Top is the number of most used labels to include, all when 0
+	Top int
+}
+
+func (a *App) newLabelsCommand() *cobra.Command {
+	labelsCmd := &cobra.Command{
+		Use:   "labels",
+		Short: "Reports about how labels are used",
+	}
+
+	matrixCmd := &cobra.Command{
+		Use:   "matrix [repository]",
+		Short: "Report how often labels are used together",
+		Long: `Show how often each pair of labels is used on the same pr.
+The diagonal is omitted; the Total column holds the number of prs per label and
+Exclusive the number of prs that only have that label.
+
+To count every pr exactly once, use --group-by primary-label with --label-priority
+on the main command.
+
+Examples:
+  gh pr-stats labels matrix owner/repo
+  gh pr-stats labels matrix owner/repo --top 10 --format csv`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runLabelsMatrix,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	matrixCmd.Flags().IntVar(&a.Options.Matrix.Top, "top", 0, "Only include the N most used labels")
+	// The top option of the configuration files limits the label rows instead
+	ignoreConfig(matrixCmd.Flags(), "top")
+	matrixCmd.Flags().StringVarP(&a.Options.Format, "format", "f", "", "Output format: table (default), json, csv or tsv")
+	matrixCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	labelsCmd.AddCommand(matrixCmd)
+	return labelsCmd
+}
+
+func (a *App) runLabelsMatrix(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+	format, err := a.commandFormat(render.Table.Name, render.JSON.Name, render.CSV.Name, render.TSV.Name)
+	if err != nil {
+		return err
+	}
+
+	top := a.Options.Matrix.Top
+	if top < 0 {
+		return fmt.Errorf("--top must not be negative")
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+
+	prs, err := a.fetchPullRequests(repository)
+	if err != nil {
+		return err
+	}
+
+	matrix := stats.CalculateLabelMatrix(prs, top)
+
+	switch format {
+	case render.JSON.Name:
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(matrix)
+	case render.CSV.Name:
+		return utils.WriteDelimitedLabelMatrix(cmd, matrix, ',')
+	case render.TSV.Name:
+		return utils.WriteDelimitedLabelMatrix(cmd, matrix, '\t')
+	case render.Table.Name:
+		utils.PrintLabelMatrix(cmd, matrix)
+	}
+
+	return nil
+}
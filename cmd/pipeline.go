@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/internal/analysis"
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// PipelineOptions holds the files of the analyze and report stages
+type PipelineOptions struct {
+	// Input is the raw prs of analyze, or the statistics of report
+	Input string
+	// Output is the file analyze writes the statistics to
+	Output string
+}
+
+// addAnalyzeFlags registers the flags grouping and arranging the statistics rows
+func (a *App) addAnalyzeFlags(flags *pflag.FlagSet) {
+	opts := &a.Options.Analysis
+	flags.StringVar(&opts.SortBy, "sort-by", "", "Sort label rows by column: <column>[:asc|desc] (default: total:desc)")
+	flags.IntVar(&opts.Top, "top", 0, "Show only the top N label rows and aggregate the rest into an \"*other*\" row")
+	flags.StringVar(&opts.GroupBy, "group-by", "label", "Group rows by: "+strings.Join(stats.GroupByOptions, ", "))
+	flags.StringSliceVar(&opts.LabelPriority, "label-priority", nil, "Comma separated label priority list used by --group-by primary-label")
+	flags.IntVar(&opts.MinCount, "min-count", 0, "Hide label rows with fewer prs than N")
+}
+
+// addReportFlags registers the flags rendering the statistics
+func (a *App) addReportFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&a.Options.Format, "format", "f", "", "Output format: "+strings.Join(a.Renderers.Names(), ", ")+" (default: "+a.Renderers.Names()[0]+")")
+	opts := &a.Options.Report
+	flags.StringVar(&opts.Columns, "columns", "", "Comma separated list of columns to output, in order (default: "+strings.Join(utils.DefaultColumns, ",")+")")
+	flags.BoolVar(&opts.ListColumns, "list-columns", false, "List the available columns and exit")
+	flags.BoolVar(&opts.ListFormats, "list-formats", false, "List the available output formats and exit")
+	flags.BoolVar(&opts.LegacyJSON, "legacy-json", false, "Write the json statistics in the shape of schema version 1, without the report envelope")
+	flags.BoolVar(&opts.JSONSchema, "json-schema", false, "Print the JSON Schema of the json report and exit")
+}
+
+func (a *App) newFetchCommand() *cobra.Command {
+	fetchCmd := &cobra.Command{
+		Use:   "fetch [repository]",
+		Short: "Download the raw prs data",
+		Long: `Download the prs of a repository to a file, by default to the cache read by analyze.
+Filter terms supported by the GitHub search API narrow the download; every filter is
+applied again by analyze.
+
+Examples:
+  gh pr-stats fetch owner/repo
+  gh pr-stats fetch owner/repo --output prs.jsonl --output-format jsonl`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runFetch,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	fetchCmd.Flags().StringVarP(&a.Options.OutputFile, "output", "o", "", "Output file for raw prs data (default: the cache of the repository)")
+	fetchCmd.Flags().StringVar(&a.Options.OutputFormat, "output-format", "json", "Format of the raw prs data: "+strings.Join(utils.RawFormats, ", "))
+	fetchCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return fetchCmd
+}
+
+func (a *App) runFetch(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+	format := a.Options.OutputFormat
+	if !utils.IsValidRawFormat(format) {
+		return fmt.Errorf("invalid output format %q. Supported formats: %s", format, strings.Join(utils.RawFormats, ", "))
+	}
+
+	expr, err := a.parseFilterFlags()
+	if err != nil {
+		return err
+	}
+	prs, err := a.downloadPullRequests(repository, expr)
+	if err != nil {
+		return err
+	}
+
+	filename := a.Options.OutputFile
+	if filename == "" {
+		if filename, err = a.cachePath(repository); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			return fmt.Errorf("failed to create cache directory: %v", err)
+		}
+		format = "json"
+	}
+	if err := utils.SavePullRequests(prs, filename, a.saveOptions(format)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Saved %d prs to %s\n", len(prs), filename)
+	return nil
+}
+
+func (a *App) newAnalyzeCommand() *cobra.Command {
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze [repository]",
+		Short: "Compute statistics from raw prs data",
+		Long: `Compute the statistics of raw prs data saved by fetch and write them as JSON.
+Filters, label normalization and grouping are applied here. Base branch filter terms
+can only be applied by fetch.
+
+Examples:
+  gh pr-stats analyze owner/repo --group-by author --output stats.json
+  gh pr-stats analyze --input prs.json | gh pr-stats report --format markdown`,
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runAnalyze,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	analyzeCmd.Flags().StringVarP(&a.Options.Pipeline.Input, "input", "i", "", "Raw prs data in the json or jsonl format (default: the cache of the repository)")
+	analyzeCmd.Flags().StringVarP(&a.Options.Pipeline.Output, "output", "o", "", "Output file for statistics data in the format of its extension, json otherwise (default: stdout)")
+	a.addAnalyzeFlags(analyzeCmd.Flags())
+	analyzeCmd.Flags().BoolVar(&a.Options.Report.LegacyJSON, "legacy-json", false, "Write the json statistics in the shape of schema version 1, without the report envelope")
+	analyzeCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return analyzeCmd
+}
+
+func (a *App) runAnalyze(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+
+	analysisOpts, err := a.analysisOptions()
+	if err != nil {
+		return err
+	}
+	expr, err := a.parseFilterFlags()
+	if err != nil {
+		return err
+	}
+
+	filename := a.Options.Pipeline.Input
+	var repositories []string
+	if filename == "" || len(args) > 0 {
+		repository, err := a.repositoryArg(args)
+		if err != nil {
+			return err
+		}
+		if filename == "" {
+			if filename, err = a.cachePath(repository); err != nil {
+				return err
+			}
+		}
+		repositories = []string{github.Qualify(repository, a.Options.Hostname)}
+	}
+	prs, err := utils.LoadPullRequests(filename, a.progress())
+	if err != nil {
+		return err
+	}
+
+	_, result, err := analysis.Analyze(a.filterPullRequests(prs, expr), analysisOpts)
+	if err != nil {
+		return err
+	}
+
+	report := a.newReport(repositories, result)
+	if a.Options.Pipeline.Output != "" {
+		targets, err := a.statsTargets([]string{a.Options.Pipeline.Output})
+		if err != nil {
+			return err
+		}
+		columns, err := utils.ParseColumns("")
+		if err != nil {
+			return err
+		}
+		return a.saveStatistics(targets, report, columns)
+	}
+	return render.JSON.Renderer.Render(cmd.OutOrStdout(), report, render.Options{Legacy: a.Options.Report.LegacyJSON})
+}
+
+func (a *App) newReportCommand() *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Render statistics data",
+		Long: `Render statistics written by analyze or --stats in any output format.
+
+Examples:
+  gh pr-stats report --input stats.json --format markdown
+  gh pr-stats analyze owner/repo | gh pr-stats report --columns label,open,p90`,
+		Args:          cobra.NoArgs,
+		RunE:          a.runReport,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	reportCmd.Flags().StringVarP(&a.Options.Pipeline.Input, "input", "i", "", "Statistics data in the json format, - for stdin (default: stdin)")
+	a.addReportFlags(reportCmd.Flags())
+	reportCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return reportCmd
+}
+
+func (a *App) runReport(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+
+	if a.Options.Report.ListColumns {
+		utils.PrintColumns(cmd)
+		return nil
+	}
+	if a.Options.Report.ListFormats {
+		render.PrintFormats(cmd.OutOrStdout(), a.Renderers)
+		return nil
+	}
+	if a.Options.Report.JSONSchema {
+		return printJSONSchema(cmd)
+	}
+
+	columns, err := utils.ParseColumns(a.Options.Report.Columns)
+	if err != nil {
+		return err
+	}
+	if _, err := a.Renderers.Lookup(a.Options.Format); err != nil {
+		return err
+	}
+
+	var input io.Reader = cmd.InOrStdin()
+	name := "stdin"
+	if filename := a.Options.Pipeline.Input; filename != "" && filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", filename, err)
+		}
+		defer file.Close()
+		input, name = file, filename
+	}
+
+	report, err := types.ReadReport(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse statistics in %s: %v", name, err)
+	}
+	// Reports read from files of schema version 1 are written in the current version
+	report.SchemaVersion = types.SchemaVersion
+
+	return a.renderStatistics(cmd, report, columns)
+}
+
+// cachePath returns the file fetch saves the raw prs of the repository to
+func (a *App) cachePath(repository string) (string, error) {
+	repository = github.Qualify(repository, a.Options.Hostname)
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %v", err)
+	}
+	return filepath.Join(dir, "gh-pr-stats", strings.ReplaceAll(repository, "/", "_")+".json"), nil
+}
+
+// analysisOptions validates the grouping flags and returns the options
+// grouping and arranging the statistics rows
+func (a *App) analysisOptions() (analysis.Options, error) {
+	opts := a.Options.Analysis
+	opts.Days = a.days
+	return opts, opts.Validate()
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// ServeOptions configures the metrics server
+type ServeOptions struct {
+	// Listen is the address to listen on
+	Listen string
+	// Interval is the interval between data refreshes
+	Interval time.Duration
+}
+
+func (a *App) newServeCommand() *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve [repository...]",
+		Short: "Serve pr statistics as Prometheus metrics",
+		Long: `Start an HTTP server exposing pr statistics of one or more repositories.
+Data is refreshed on the given interval by fetching only the prs updated since the last sync.
+
+Endpoints:
+  /metrics  Prometheus metrics
+  /stats    Statistics per repository as JSON
+  /healthz  Health check
+
+Examples:
+  # Current repository
+  gh pr-stats serve
+
+  # Multiple repositories refreshed every 10 minutes
+  gh pr-stats serve owner/repo owner/other --interval 10m --listen :9101
+
+  # Repositories of github.com and a GitHub Enterprise Server host
+  gh pr-stats serve owner/repo github.example.com/team/service`,
+		RunE:          a.runServe,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	serveCmd.Flags().StringVarP(&a.Options.Serve.Listen, "listen", "l", ":9101", "Address to listen on")
+	serveCmd.Flags().DurationVarP(&a.Options.Serve.Interval, "interval", "i", 5*time.Minute, "Interval between data refreshes")
+	serveCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return serveCmd
+}
+
+func (a *App) runServe(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+
+	prFilter, err := a.newServeFilter()
+	if err != nil {
+		return err
+	}
+	opts := a.Options.Serve
+	if opts.Interval <= 0 {
+		return fmt.Errorf("invalid interval %s: must be positive", opts.Interval)
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = a.configRepositories
+	}
+	var repositories []string
+	for _, name := range names {
+		repo, err := github.ParseRepository(name)
+		if err != nil {
+			return err
+		}
+		repositories = append(repositories, repo.String())
+	}
+	if len(repositories) == 0 {
+		current, err := github.CurrentRepository(a.Options.Remote)
+		if err != nil {
+			return fmt.Errorf("failed to get current repository: %w", err)
+		}
+		repositories = []string{current.String()}
+	}
+
+	fetcher, err := a.fetcher()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Serving statistics of %d repositories on %s\n", len(repositories), opts.Listen)
+
+	srv := server.New(repositories, opts.Interval, fetcher.SyncPullRequests)
+	srv.Filter = prFilter
+	srv.Days = a.days
+	srv.Logger = a.Logger
+	srv.Report = a.newReport
+	return srv.ListenAndServe(ctx, opts.Listen)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// SizeOptions configures the size report
+type SizeOptions struct {
+	// Thresholds are the comma separated upper bounds of the size buckets
+	Thresholds string
+}
+
+func (a *App) newSizeCommand() *cobra.Command {
+	sizeCmd := &cobra.Command{
+		Use:   "size [repository]",
+		Short: "Report merge statistics by pr size",
+		Long: fmt.Sprintf(`Fetch the additions, deletions, changed files and commits of every pr, bucket the prs
+into %s by changed lines, and report the merge rate and median time to merge per bucket
+along with the size percentiles per label.
+
+This fetches the details of every pr and can take a while on large repositories.
+
+Examples:
+  gh pr-stats size owner/repo
+  gh pr-stats size owner/repo --size-thresholds 20,200,800,2000 --format json`, strings.Join(stats.SizeNames, "/")),
+		Args:          cobra.MaximumNArgs(1),
+		RunE:          a.runSize,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	sizeCmd.Flags().StringVar(&a.Options.Size.Thresholds, "size-thresholds", joinInts(stats.DefaultSizeThresholds), "Exclusive upper bounds of changed lines of the "+strings.Join(stats.SizeNames[:len(stats.SizeNames)-1], ", ")+" buckets")
+	sizeCmd.Flags().StringVarP(&a.Options.Format, "format", "f", "", "Output format: table (default) or json")
+	sizeCmd.Flags().BoolVarP(&a.Options.Debug, "debug", "v", false, "Enable verbose debug output")
+
+	return sizeCmd
+}
+
+func (a *App) runSize(cmd *cobra.Command, args []string) error {
+	if err := a.setup(cmd); err != nil {
+		return err
+	}
+	format, err := a.commandFormat(render.Table.Name, render.JSON.Name)
+	if err != nil {
+		return err
+	}
+
+	thresholds, err := stats.ParseSizeThresholds(a.Options.Size.Thresholds)
+	if err != nil {
+		return err
+	}
+
+	repository, err := a.repositoryArg(args)
+	if err != nil {
+		return err
+	}
+
+	prs, err := a.fetchPullRequests(repository)
+	if err != nil {
+		return err
+	}
+	if prs, err = a.withSizes(repository, prs); err != nil {
+		return err
+	}
+
+	report := stats.CalculateSizes(prs, thresholds, a.days)
+
+	switch format {
+	case render.JSON.Name:
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case render.Table.Name:
+		utils.PrintSizeReport(cmd, report)
+	}
+
+	return nil
+}
+
+// withSizes fetches the sizes of the prs and sets them on a copy of prs
+func (a *App) withSizes(repository string, prs []types.PullRequest) ([]types.PullRequest, error) {
+	fetcher, err := a.fetcher()
+	if err != nil {
+		return nil, err
+	}
+	sizes, err := fetcher.FetchSizes(repository, prs)
+	if err != nil {
+		return nil, err
+	}
+
+	sized := make([]types.PullRequest, len(prs))
+	for i, pr := range prs {
+		if size, ok := sizes[pr.Number]; ok {
+			pr.Size = &size
+		}
+		sized[i] = pr
+	}
+	return sized, nil
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%d", v)
+	}
+	return strings.Join(parts, ",")
+}
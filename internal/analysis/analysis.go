@@ -0,0 +1,86 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Options groups, sorts and limits the statistics rows. The zero value
+// groups the rows by label in the order of the statistics.
+type Options struct {
+	// GroupBy is one of stats.GroupByOptions, label when empty
+	GroupBy string
+	// LabelPriority is the priority list of the primary-label group
+	LabelPriority []string
+
+	// SortBy sorts the rows by column, e.g. median:desc
+	SortBy string
+	// Top keeps the top rows and aggregates the rest into stats.OtherLabel
+	Top int
+	// MinCount hides rows with fewer prs
+	MinCount int
+
+	// Days measures the durations, calendar days when nil
+	Days stats.DaysFunc
+}
+
+func (o Options) groupBy() string {
+	if o.GroupBy == "" {
+		return "label"
+	}
+	return o.GroupBy
+}
+
+// arrange validates the options and returns how the rows are arranged
+func (o Options) arrange() (stats.ArrangeOptions, error) {
+	opts := stats.ArrangeOptions{Top: o.Top, MinCount: o.MinCount, Days: o.Days}
+	if o.SortBy != "" {
+		var err error
+		if opts.Less, err = utils.ParseSortBy(o.SortBy); err != nil {
+			return opts, err
+		}
+	}
+	if o.Top < 0 || o.MinCount < 0 {
+		return opts, fmt.Errorf("top and min count must not be negative")
+	}
+	if groupBy := o.groupBy(); !slices.Contains(stats.GroupByOptions, groupBy) {
+		return opts, fmt.Errorf("invalid group %q. Supported: %s", groupBy, strings.Join(stats.GroupByOptions, ", "))
+	}
+	return opts, nil
+}
+
+// Validate reports invalid options, e.g. before any pr is fetched
+func (o Options) Validate() error {
+	_, err := o.arrange()
+	return err
+}
+
+// Analyze calculates the statistics of every pr, and the statistics grouped
+// and arranged by the options
+func Analyze(prs []types.PullRequest, opts Options) (types.Statistics, types.Statistics, error) {
+	arrange, err := opts.arrange()
+	if err != nil {
+		return types.Statistics{}, types.Statistics{}, err
+	}
+
+	allStats := stats.CalculateStatistics(prs, opts.Days)
+
+	// Group the rows by label, primary label, author or author type
+	groupBy := opts.groupBy()
+	grouped, err := stats.Regroup(prs, groupBy, opts.LabelPriority)
+	if err != nil {
+		return allStats, types.Statistics{}, err
+	}
+	groupedStats := allStats
+	if groupBy != "label" {
+		groupedStats = stats.CalculateStatistics(grouped, opts.Days)
+	}
+
+	// Filter, sort and limit the rows of every output
+	return allStats, stats.Arrange(grouped, groupedStats, arrange), nil
+}
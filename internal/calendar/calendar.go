@@ -0,0 +1,169 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Calendar describes the working time used to measure business durations
+type Calendar struct {
+	Location *time.Location
+	// DayStart and DayEnd are the working hours as offsets from midnight
+	DayStart time.Duration
+	DayEnd   time.Duration
+	Weekend  map[time.Weekday]bool
+	// Holidays are non-working dates formatted as YYYY-MM-DD
+	Holidays map[string]bool
+}
+
+// Default returns a calendar working 09:00-17:00 in UTC, Monday to Friday
+func Default() *Calendar {
+	return &Calendar{
+		Location: time.UTC,
+		DayStart: 9 * time.Hour,
+		DayEnd:   17 * time.Hour,
+		Weekend:  map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		Holidays: make(map[string]bool),
+	}
+}
+
+// SetWorkingHours parses working hours of the form 09:00-17:00
+func (c *Calendar) SetWorkingHours(spec string) error {
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return fmt.Errorf("invalid working hours %q. Expected format: HH:MM-HH:MM", spec)
+	}
+
+	start, err := parseClock(from)
+	if err != nil {
+		return fmt.Errorf("invalid working hours %q: %v", spec, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return fmt.Errorf("invalid working hours %q: %v", spec, err)
+	}
+	if end <= start {
+		return fmt.Errorf("invalid working hours %q: end must be after start", spec)
+	}
+
+	c.DayStart, c.DayEnd = start, end
+	return nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// SetWeekend parses a comma separated list of weekday names such as sat,sun
+func (c *Calendar) SetWeekend(spec string) error {
+	weekend := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		day, ok := parseWeekday(name)
+		if !ok {
+			return fmt.Errorf("invalid weekday %q", name)
+		}
+		weekend[day] = true
+	}
+	if len(weekend) == 7 {
+		return fmt.Errorf("invalid weekend %q: at least one working day is required", spec)
+	}
+
+	c.Weekend = weekend
+	return nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// SetTimezone sets the location of the working hours, e.g. Europe/Berlin
+func (c *Calendar) SetTimezone(name string) error {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", name, err)
+	}
+	c.Location = location
+	return nil
+}
+
+// AddHoliday marks the given date as a non-working day
+func (c *Calendar) AddHoliday(date time.Time) {
+	c.Holidays[date.Format(dateLayout)] = true
+}
+
+// IsWorkingDay reports whether the date in the calendar location is a working day
+func (c *Calendar) IsWorkingDay(date time.Time) bool {
+	date = date.In(c.Location)
+	return !c.Weekend[date.Weekday()] && !c.Holidays[date.Format(dateLayout)]
+}
+
+// WorkingDuration returns the working time between from and to
+func (c *Calendar) WorkingDuration(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+
+	from, to = from.In(c.Location), to.In(c.Location)
+
+	var total time.Duration
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, c.Location)
+	for !day.After(to) {
+		if c.IsWorkingDay(day) {
+			start := maxTime(from, c.clock(day, c.DayStart))
+			end := minTime(to, c.clock(day, c.DayEnd))
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// clock returns the wall clock time of the offset from midnight on day. The
+// offset is not added to midnight, which is off by an hour on the days the
+// clocks change.
+func (c *Calendar) clock(day time.Time, offset time.Duration) time.Time {
+	hours, minutes := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hours, minutes, 0, 0, c.Location)
+}
+
+// WorkingDays returns the working time between from and to in working days
+func (c *Calendar) WorkingDays(from, to time.Time) float64 {
+	return c.WorkingDuration(from, to).Hours() / (c.DayEnd - c.DayStart).Hours()
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkingDuration(t *testing.T) {
+	cal := Default()
+	cal.AddHoliday(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		from, to string
+		expected time.Duration
+	}{
+		{"Same working day", "2024-12-02T10:00:00Z", "2024-12-02T12:30:00Z", 150 * time.Minute},
+		{"Outside working hours", "2024-12-02T18:00:00Z", "2024-12-03T08:00:00Z", 0},
+		{"Over a weekend", "2024-12-06T16:00:00Z", "2024-12-09T10:00:00Z", 2 * time.Hour},
+		{"Over a holiday", "2024-12-24T16:00:00Z", "2024-12-26T10:00:00Z", 2 * time.Hour},
+		{"Reversed", "2024-12-03T10:00:00Z", "2024-12-02T10:00:00Z", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			to, _ := time.Parse(time.RFC3339, tt.to)
+			assert.Equal(t, tt.expected, cal.WorkingDuration(from, to))
+		})
+	}
+}
+
+func TestWorkingDurationOverDaylightSavingTime(t *testing.T) {
+	cal := Default()
+	assert.NoError(t, cal.SetTimezone("Europe/Berlin"))
+	assert.NoError(t, cal.SetWeekend("sat"))
+
+	tests := []struct {
+		name     string
+		from, to string
+		expected time.Duration
+	}{
+		// 09:00 to 10:00 CEST on the day the clocks go forward
+		{"Spring forward", "2024-03-31T07:00:00Z", "2024-03-31T08:00:00Z", time.Hour},
+		// 08:00 to 09:00 CET on the day the clocks go back
+		{"Fall back", "2024-10-27T07:00:00Z", "2024-10-27T08:00:00Z", 0},
+		{"Whole day", "2024-10-26T22:00:00Z", "2024-10-27T23:00:00Z", 8 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			to, _ := time.Parse(time.RFC3339, tt.to)
+			assert.Equal(t, tt.expected, cal.WorkingDuration(from, to))
+		})
+	}
+}
+
+func TestWorkingDaysInTimezone(t *testing.T) {
+	cal := Default()
+	assert.NoError(t, cal.SetTimezone("Asia/Tokyo"))
+	assert.NoError(t, cal.SetWorkingHours("10:00-18:00"))
+	assert.NoError(t, cal.SetWeekend("fri,sat"))
+
+	// Sunday 10:00 to Monday 18:00 in Tokyo is two full working days
+	from, _ := time.Parse(time.RFC3339, "2024-12-08T01:00:00Z")
+	to, _ := time.Parse(time.RFC3339, "2024-12-09T09:00:00Z")
+	assert.Equal(t, 2.0, cal.WorkingDays(from, to))
+
+	assert.Error(t, cal.SetWorkingHours("18:00-10:00"))
+	assert.Error(t, cal.SetWeekend("sun,mon,tue,wed,thu,fri,sat"))
+}
+
+func TestLoadHolidays(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "holidays.yml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte("holidays:\n  - 2024-12-25\n  - date: 2024-12-26\n    name: Boxing Day\n"), 0o644))
+
+	icsFile := filepath.Join(dir, "holidays.ics")
+	assert.NoError(t, os.WriteFile(icsFile, []byte(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250101
+DTEND;VALUE=DATE:20250103
+SUMMARY:New Year
+END:VEVENT
+END:VCALENDAR
+`), 0o644))
+
+	cal := Default()
+	assert.NoError(t, cal.LoadHolidays(yamlFile))
+	assert.NoError(t, cal.LoadHolidays(icsFile))
+	assert.Equal(t, map[string]bool{
+		"2024-12-25": true,
+		"2024-12-26": true,
+		"2025-01-01": true,
+		"2025-01-02": true,
+	}, cal.Holidays)
+}
+
+func TestLoadHolidaysRecurring(t *testing.T) {
+	icsFile := filepath.Join(t.TempDir(), "holidays.ics")
+	assert.NoError(t, os.WriteFile(icsFile, []byte(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20241225
+RRULE:FREQ=YEARLY
+SUMMARY:Christmas
+END:VEVENT
+END:VCALENDAR
+`), 0o644))
+
+	cal := Default()
+	assert.ErrorContains(t, cal.LoadHolidays(icsFile), `recurring events are not supported: "RRULE:FREQ=YEARLY"`)
+	assert.Empty(t, cal.Holidays)
+}
@@ -0,0 +1,128 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// holidayFile is the YAML holiday list. Either a plain list of dates or a
+// mapping with a holidays key is accepted.
+type holidayFile struct {
+	Holidays []holidayEntry `yaml:"holidays"`
+}
+
+type holidayEntry struct {
+	Date string `yaml:"date"`
+	Name string `yaml:"name"`
+}
+
+// UnmarshalYAML accepts both `- 2024-12-25` and `- date: 2024-12-25`
+func (h *holidayEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		h.Date = node.Value
+		return nil
+	}
+	type plain holidayEntry
+	return node.Decode((*plain)(h))
+}
+
+// LoadHolidays adds the holidays of an ICS (.ics) or YAML file to the calendar
+func (c *Calendar) LoadHolidays(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read holidays %s: %v", filename, err)
+	}
+
+	var dates []time.Time
+	if strings.EqualFold(filepath.Ext(filename), ".ics") {
+		dates, err = parseICS(data)
+	} else {
+		dates, err = parseHolidayYAML(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse holidays %s: %v", filename, err)
+	}
+
+	for _, date := range dates {
+		c.AddHoliday(date)
+	}
+	return nil
+}
+
+func parseHolidayYAML(data []byte) ([]time.Time, error) {
+	var file holidayFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		var entries []holidayEntry
+		if listErr := yaml.Unmarshal(data, &entries); listErr != nil {
+			return nil, err
+		}
+		file.Holidays = entries
+	}
+
+	dates := make([]time.Time, 0, len(file.Holidays))
+	for _, entry := range file.Holidays {
+		date, err := time.Parse(dateLayout, strings.TrimSpace(entry.Date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q. Expected format: YYYY-MM-DD", entry.Date)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// parseICS returns every date covered by the VEVENTs of an iCalendar file.
+// DTEND is exclusive as defined by RFC 5545. Recurring events are rejected
+// instead of counting only their first occurrence.
+func parseICS(data []byte) ([]time.Time, error) {
+	var dates []time.Time
+	var start, end *time.Time
+	inEvent := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent, start, end = true, nil, nil
+		case line == "END:VEVENT":
+			if start == nil {
+				return nil, fmt.Errorf("event without DTSTART")
+			}
+			last := start.AddDate(0, 0, 1)
+			if end != nil && end.After(*start) {
+				last = *end
+			}
+			for day := *start; day.Before(last); day = day.AddDate(0, 0, 1) {
+				dates = append(dates, day)
+			}
+			inEvent = false
+		case inEvent && (strings.HasPrefix(line, "RRULE") || strings.HasPrefix(line, "RDATE")):
+			return nil, fmt.Errorf("recurring events are not supported: %q. List every occurrence as an event", line)
+		case inEvent && (strings.HasPrefix(line, "DTSTART") || strings.HasPrefix(line, "DTEND")):
+			_, value, ok := strings.Cut(line, ":")
+			if !ok || len(value) < 8 {
+				return nil, fmt.Errorf("invalid line %q", line)
+			}
+			date, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("invalid date in %q", line)
+			}
+			if strings.HasPrefix(line, "DTSTART") {
+				start = &date
+			} else {
+				end = &date
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dates, nil
+}
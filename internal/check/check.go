@@ -0,0 +1,149 @@
+package check
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// metrics maps the metric names usable in rules to their values
+var metrics = map[string]func(types.LabelStat) float64{
+	"total":                func(s types.LabelStat) float64 { return float64(s.Total) },
+	"open":                 func(s types.LabelStat) float64 { return float64(s.Open) },
+	"closed":               func(s types.LabelStat) float64 { return float64(s.Closed) },
+	"merged":               func(s types.LabelStat) float64 { return float64(s.Merged) },
+	"open_percentage":      func(s types.LabelStat) float64 { return s.OpenPercentage },
+	"avg_days_to_close":    func(s types.LabelStat) float64 { return s.AvgDaysToClose },
+	"median_days_to_close": func(s types.LabelStat) float64 { return s.MedianDaysToClose },
+	"p90_days_to_close":    func(s types.LabelStat) float64 { return s.P90DaysToClose },
+}
+
+var operators = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// rulePattern matches `[overall.|label[name].]metric op threshold`
+var rulePattern = regexp.MustCompile(`^\s*(?:(overall)\.|label\[([^\]]+)\]\.)?([a-z0-9_]+)\s*(>=|<=|==|!=|>|<)\s*(-?[0-9.]+)\s*$`)
+
+// Rule is a threshold that fails when the condition is true
+type Rule struct {
+	Expression string
+	// Label is the label the rule applies to, or empty for the overall statistics
+	Label     string
+	Metric    string
+	Operator  string
+	Threshold float64
+}
+
+// Result is the outcome of evaluating a rule
+type Result struct {
+	Rule  Rule
+	Value float64
+	// Undefined reports that the metric has no value, e.g. NaN. Such rules
+	// fail, since the threshold cannot be verified.
+	Undefined bool
+	Failed    bool
+}
+
+// ParseRule parses an expression such as `overall.median_days_to_close > 3`,
+// `label[bug].open > 20` or `open_percentage > 15`
+func ParseRule(expression string) (Rule, error) {
+	m := rulePattern.FindStringSubmatch(expression)
+	if m == nil {
+		return Rule{}, fmt.Errorf("invalid rule %q. Expected format: [overall.|label[name].]metric operator number", expression)
+	}
+
+	metric := m[3]
+	if _, ok := metrics[metric]; !ok {
+		return Rule{}, fmt.Errorf("invalid rule %q: unknown metric %s. Available metrics: %s", expression, metric, strings.Join(MetricNames(), ", "))
+	}
+
+	threshold, err := strconv.ParseFloat(m[5], 64)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: invalid threshold %s", expression, m[5])
+	}
+
+	return Rule{
+		Expression: strings.TrimSpace(expression),
+		Label:      m[2],
+		Metric:     metric,
+		Operator:   m[4],
+		Threshold:  threshold,
+	}, nil
+}
+
+// ParseRules parses every expression
+func ParseRules(expressions []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(expressions))
+	for _, expression := range expressions {
+		rule, err := ParseRule(expression)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MetricNames returns the metric names usable in rules
+func MetricNames() []string {
+	return []string{"total", "open", "closed", "merged", "open_percentage", "avg_days_to_close", "median_days_to_close", "p90_days_to_close"}
+}
+
+// Evaluate evaluates the rule against the statistics. A label without any pr
+// has every metric at zero. A metric without a value, such as NaN, fails
+// the rule.
+func (r Rule) Evaluate(stats types.Statistics) Result {
+	stat := types.LabelStat{Name: r.Label}
+	if r.Label == "" {
+		overall := stats.OverallStats
+		stat = types.LabelStat{
+			Total:             overall.Total,
+			Open:              overall.Open,
+			Closed:            overall.Closed,
+			Merged:            overall.Merged,
+			OpenPercentage:    overall.OpenPercentage,
+			AvgDaysToClose:    overall.AvgDaysToClose,
+			MedianDaysToClose: overall.MedianDaysToClose,
+			P90DaysToClose:    overall.P90DaysToClose,
+		}
+	} else {
+		for _, s := range stats.LabelStats {
+			if s.Name == r.Label {
+				stat = s
+				break
+			}
+		}
+	}
+
+	value := metrics[r.Metric](stat)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Result{Rule: r, Value: value, Undefined: true, Failed: true}
+	}
+	return Result{
+		Rule:   r,
+		Value:  value,
+		Failed: operators[r.Operator](value, r.Threshold),
+	}
+}
+
+// Evaluate evaluates every rule and reports whether any of them failed
+func Evaluate(rules []Rule, stats types.Statistics) ([]Result, bool) {
+	results := make([]Result, 0, len(rules))
+	failed := false
+	for _, rule := range rules {
+		result := rule.Evaluate(stats)
+		failed = failed || result.Failed
+		results = append(results, result)
+	}
+	return results, failed
+}
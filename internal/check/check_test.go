@@ -0,0 +1,80 @@
+package check
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   Rule
+		expectErr  string
+	}{
+		{
+			expression: "overall.median_days_to_close > 3",
+			expected:   Rule{Expression: "overall.median_days_to_close > 3", Metric: "median_days_to_close", Operator: ">", Threshold: 3},
+		},
+		{
+			expression: " label[area/ui].open>=20 ",
+			expected:   Rule{Expression: "label[area/ui].open>=20", Label: "area/ui", Metric: "open", Operator: ">=", Threshold: 20},
+		},
+		{expression: "open_percentage", expectErr: "Expected format"},
+		{expression: "unknown > 1", expectErr: "unknown metric unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			rule, err := ParseRule(tt.expression)
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	stats := types.Statistics{
+		LabelStats:   []types.LabelStat{{Name: "bug", Open: 3, Total: 4}},
+		OverallStats: types.OverallStats{Open: 3, Total: 10, MedianDaysToClose: 2},
+	}
+
+	tests := []struct {
+		expression string
+		stats      types.Statistics
+		failed     bool
+		undefined  bool
+	}{
+		{expression: "overall.median_days_to_close > 3", stats: stats},
+		{expression: "label[bug].open > 2", stats: stats, failed: true},
+		// A label without prs has every metric at zero
+		{expression: "label[missing].open > 0", stats: stats},
+		// A NaN metric cannot pass any threshold
+		{expression: "open_percentage > 15", stats: types.Statistics{OverallStats: types.OverallStats{OpenPercentage: math.NaN()}}, failed: true, undefined: true},
+		{expression: "label[bug].avg_days_to_close <= 1", stats: types.Statistics{LabelStats: []types.LabelStat{{Name: "bug", AvgDaysToClose: math.Inf(1)}}}, failed: true, undefined: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			rule, err := ParseRule(tt.expression)
+			require.NoError(t, err)
+
+			result := rule.Evaluate(tt.stats)
+			assert.Equal(t, tt.failed, result.Failed)
+			assert.Equal(t, tt.undefined, result.Undefined)
+		})
+	}
+
+	rules, err := ParseRules([]string{"overall.total > 100", "label[bug].open > 2"})
+	require.NoError(t, err)
+	results, failed := Evaluate(rules, stats)
+	assert.True(t, failed)
+	assert.Len(t, results, 2)
+}
@@ -0,0 +1,152 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Delta is the change of a single metric between the base and head statistics
+type Delta struct {
+	Base  float64 `json:"base"`
+	Head  float64 `json:"head"`
+	Delta float64 `json:"delta"`
+	// PercentChange is nil when the base value is zero
+	PercentChange *float64 `json:"percentChange"`
+}
+
+// Diff holds the deltas of every compared metric of a label or the overall row
+type Diff struct {
+	Name              string `json:"name"`
+	Total             Delta  `json:"total"`
+	Open              Delta  `json:"open"`
+	Closed            Delta  `json:"closed"`
+	Merged            Delta  `json:"merged"`
+	OpenPercentage    Delta  `json:"openPercentage"`
+	AvgDaysToClose    Delta  `json:"avgDaysToClose"`
+	MedianDaysToClose Delta  `json:"medianDaysToClose"`
+}
+
+// Comparison is the diff between two statistics
+type Comparison struct {
+	Labels  []Diff `json:"labels"`
+	Overall Diff   `json:"overall"`
+}
+
+// Metric describes a compared metric
+type Metric struct {
+	Name   string
+	Header string
+	// HigherIsBetter is nil for metrics where neither direction is better
+	HigherIsBetter *bool
+	// Precision is the number of decimals shown in tables
+	Precision int
+	Get       func(Diff) Delta
+}
+
+var (
+	better = true
+	worse  = false
+)
+
+// Metrics lists the compared metrics in output order
+var Metrics = []Metric{
+	{Name: "total", Header: "Total", Get: func(d Diff) Delta { return d.Total }},
+	{Name: "open", Header: "Open", HigherIsBetter: &worse, Get: func(d Diff) Delta { return d.Open }},
+	{Name: "closed", Header: "Closed", HigherIsBetter: &better, Get: func(d Diff) Delta { return d.Closed }},
+	{Name: "merged", Header: "Merged", HigherIsBetter: &better, Get: func(d Diff) Delta { return d.Merged }},
+	{Name: "open_pct", Header: "Open %", Precision: 2, HigherIsBetter: &worse, Get: func(d Diff) Delta { return d.OpenPercentage }},
+	{Name: "avg", Header: "Average Time to close (days)", Precision: 2, HigherIsBetter: &worse, Get: func(d Diff) Delta { return d.AvgDaysToClose }},
+	{Name: "median", Header: "Median Time to close (days)", Precision: 2, HigherIsBetter: &worse, Get: func(d Diff) Delta { return d.MedianDaysToClose }},
+}
+
+func newDelta(base, head float64) Delta {
+	d := Delta{Base: base, Head: head, Delta: head - base}
+	if base != 0 {
+		pct := (head - base) / base * 100
+		d.PercentChange = &pct
+	}
+	return d
+}
+
+func labelDiff(name string, base, head types.LabelStat) Diff {
+	return Diff{
+		Name:              name,
+		Total:             newDelta(float64(base.Total), float64(head.Total)),
+		Open:              newDelta(float64(base.Open), float64(head.Open)),
+		Closed:            newDelta(float64(base.Closed), float64(head.Closed)),
+		Merged:            newDelta(float64(base.Merged), float64(head.Merged)),
+		OpenPercentage:    newDelta(base.OpenPercentage, head.OpenPercentage),
+		AvgDaysToClose:    newDelta(base.AvgDaysToClose, head.AvgDaysToClose),
+		MedianDaysToClose: newDelta(base.MedianDaysToClose, head.MedianDaysToClose),
+	}
+}
+
+func overallAsLabel(o types.OverallStats) types.LabelStat {
+	return types.LabelStat{
+		Total:             o.Total,
+		Open:              o.Open,
+		Closed:            o.Closed,
+		Merged:            o.Merged,
+		OpenPercentage:    o.OpenPercentage,
+		AvgDaysToClose:    o.AvgDaysToClose,
+		MedianDaysToClose: o.MedianDaysToClose,
+	}
+}
+
+// Compare calculates the diff from base to head. Labels are ordered as in
+// head, followed by the labels only present in base.
+func Compare(base, head types.Statistics) Comparison {
+	baseLabels := make(map[string]types.LabelStat, len(base.LabelStats))
+	for _, stat := range base.LabelStats {
+		baseLabels[stat.Name] = stat
+	}
+
+	var diffs []Diff
+	seen := make(map[string]bool, len(head.LabelStats))
+	for _, stat := range head.LabelStats {
+		diffs = append(diffs, labelDiff(stat.Name, baseLabels[stat.Name], stat))
+		seen[stat.Name] = true
+	}
+	for _, stat := range base.LabelStats {
+		if !seen[stat.Name] {
+			diffs = append(diffs, labelDiff(stat.Name, stat, types.LabelStat{}))
+		}
+	}
+
+	return Comparison{
+		Labels:  diffs,
+		Overall: labelDiff("Total", overallAsLabel(base.OverallStats), overallAsLabel(head.OverallStats)),
+	}
+}
+
+// CreatedBetween returns the prs created in [from, to)
+func CreatedBetween(prs []types.PullRequest, from, to time.Time) []types.PullRequest {
+	var filtered []types.PullRequest
+	for _, pr := range prs {
+		if pr.CreatedAt == nil {
+			continue
+		}
+		if !pr.CreatedAt.Before(from) && pr.CreatedAt.Before(to) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+// LoadStatistics reads statistics saved with --stats, of any schema version
+func LoadStatistics(filename string) (types.Statistics, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return types.Statistics{}, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	report, err := types.ReadReport(file)
+	if err != nil {
+		return types.Statistics{}, fmt.Errorf("failed to parse statistics in %s: %v", filename, err)
+	}
+	return report.Statistics, nil
+}
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the name of the repository level configuration file
+const RepoFileName = ".gh-pr-stats.yml"
+
+// Config holds the options of a configuration file. Every option has the
+// same meaning as the command line flag of the same name.
+type Config struct {
+	// Repositories are analyzed when no repository argument is given
+	Repositories []string `yaml:"repositories"`
+	// Hostname is the GitHub host of the repositories without a host
+	Hostname string `yaml:"hostname"`
+	// Concurrency is the number of parallel requests fetching pr details
+	Concurrency *int `yaml:"concurrency"`
+
+	Format   string   `yaml:"format"`
+	Columns  []string `yaml:"columns"`
+	SortBy   string   `yaml:"sort-by"`
+	Top      *int     `yaml:"top"`
+	MinCount *int     `yaml:"min-count"`
+	GroupBy  string   `yaml:"group-by"`
+
+	Filter         string   `yaml:"filter"`
+	Since          string   `yaml:"since"`
+	ExcludeAuthors []string `yaml:"exclude-authors"`
+	ExcludeBots    *bool    `yaml:"exclude-bots"`
+	OnlyBots       *bool    `yaml:"only-bots"`
+
+	Labels     Labels     `yaml:"labels"`
+	Thresholds Thresholds `yaml:"thresholds"`
+	Calendar   Calendar   `yaml:"calendar"`
+
+	// Profiles are named sets of options selected with --profile
+	Profiles map[string]Config `yaml:"profiles"`
+}
+
+// Labels holds the label normalization options
+type Labels struct {
+	IgnoreCase *bool               `yaml:"ignore-case"`
+	Aliases    map[string][]string `yaml:"aliases"`
+	Prefix     string              `yaml:"prefix"`
+	Include    []string            `yaml:"include"`
+	Exclude    []string            `yaml:"exclude"`
+	Priority   []string            `yaml:"priority"`
+}
+
+// Thresholds holds the thresholds of the aging, size and check commands
+type Thresholds struct {
+	StaleAfter string   `yaml:"stale-after"`
+	Size       []int    `yaml:"size"`
+	FailOn     []string `yaml:"fail-on"`
+}
+
+// Calendar holds the business calendar options
+type Calendar struct {
+	BusinessTime *bool    `yaml:"business-time"`
+	Timezone     string   `yaml:"timezone"`
+	WorkingHours string   `yaml:"working-hours"`
+	Weekend      []string `yaml:"weekend"`
+	Holidays     string   `yaml:"holidays"`
+}
+
+// File is a configuration file loaded from Path
+type File struct {
+	Path   string
+	Config Config
+}
+
+// Setting is the value of a command line flag taken from a configuration file
+type Setting struct {
+	Flag   string
+	Values []string
+	Source string
+}
+
+// Parse decodes a configuration, rejecting unknown options
+func Parse(data []byte) (Config, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// LoadFile reads and parses the configuration file at path
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	// Relative paths are relative to the configuration file
+	cfg.resolvePaths(filepath.Dir(path))
+	for name, profile := range cfg.Profiles {
+		profile.resolvePaths(filepath.Dir(path))
+		cfg.Profiles[name] = profile
+	}
+
+	return &File{Path: path, Config: cfg}, nil
+}
+
+func (c *Config) resolvePaths(dir string) {
+	if c.Calendar.Holidays != "" && !filepath.IsAbs(c.Calendar.Holidays) {
+		c.Calendar.Holidays = filepath.Join(dir, c.Calendar.Holidays)
+	}
+}
+
+// UserPath returns the path of the user level configuration file,
+// $XDG_CONFIG_HOME/gh-pr-stats/config.yml or ~/.config/gh-pr-stats/config.yml
+func UserPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh-pr-stats", "config.yml")
+}
+
+// RepoPath returns the path of the repository level configuration file in
+// dir or its closest parent directory having one. The search stops at the
+// root of the git repository.
+func RepoPath(dir string) string {
+	for {
+		path := filepath.Join(dir, RepoFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the user and the repository configuration files that exist, in
+// order of increasing precedence
+func Load() ([]*File, error) {
+	var paths []string
+	if path := UserPath(); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if path := RepoPath(cwd); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	files := make([]*File, 0, len(paths))
+	for _, path := range paths {
+		file, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Profile returns the named profile of the file with the highest precedence
+// defining it, as a file taking precedence over the others
+func Profile(files []*File, name string) (*File, error) {
+	for i := len(files) - 1; i >= 0; i-- {
+		if profile, ok := files[i].Config.Profiles[name]; ok {
+			return &File{Path: fmt.Sprintf("%s (profile %s)", files[i].Path, name), Config: profile}, nil
+		}
+	}
+
+	names := ProfileNames(files)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("profile %q not found: no profiles are configured", name)
+	}
+	return nil, fmt.Errorf("profile %q not found. Available profiles: %s", name, strings.Join(names, ", "))
+}
+
+// ProfileNames returns the sorted names of the profiles of the files
+func ProfileNames(files []*File) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, file := range files {
+		for name := range file.Config.Profiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Repositories returns the repositories of the file with the highest precedence listing any
+func Repositories(files []*File) []string {
+	for i := len(files) - 1; i >= 0; i-- {
+		if len(files[i].Config.Repositories) > 0 {
+			return files[i].Config.Repositories
+		}
+	}
+	return nil
+}
+
+// Resolve returns the flag values of the files, the later files overriding
+// the earlier ones, ordered by flag name
+func Resolve(files []*File) []Setting {
+	settings := make(map[string]Setting)
+	for _, file := range files {
+		for flag, values := range file.Config.flags() {
+			settings[flag] = Setting{Flag: flag, Values: values, Source: file.Path}
+		}
+	}
+
+	resolved := make([]Setting, 0, len(settings))
+	for _, setting := range settings {
+		resolved = append(resolved, setting)
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Flag < resolved[j].Flag })
+	return resolved
+}
+
+// flags returns the options that are set as command line flag values
+func (c Config) flags() map[string][]string {
+	flags := make(map[string][]string)
+	set := func(flag string, values ...string) {
+		if len(values) > 0 && (len(values) > 1 || values[0] != "") {
+			flags[flag] = values
+		}
+	}
+	setBool := func(flag string, value *bool) {
+		if value != nil {
+			flags[flag] = []string{strconv.FormatBool(*value)}
+		}
+	}
+	setInt := func(flag string, value *int) {
+		if value != nil {
+			flags[flag] = []string{strconv.Itoa(*value)}
+		}
+	}
+
+	set("hostname", c.Hostname)
+	setInt("concurrency", c.Concurrency)
+	set("format", c.Format)
+	set("columns", strings.Join(c.Columns, ","))
+	set("sort-by", c.SortBy)
+	setInt("top", c.Top)
+	setInt("min-count", c.MinCount)
+	set("group-by", c.GroupBy)
+
+	set("filter", c.Filter)
+	set("since", c.Since)
+	set("exclude-author", c.ExcludeAuthors...)
+	setBool("exclude-bots", c.ExcludeBots)
+	setBool("only-bots", c.OnlyBots)
+
+	setBool("label-ignore-case", c.Labels.IgnoreCase)
+	canonicals := make([]string, 0, len(c.Labels.Aliases))
+	for canonical := range c.Labels.Aliases {
+		canonicals = append(canonicals, canonical)
+	}
+	sort.Strings(canonicals)
+	aliases := make([]string, 0, len(canonicals))
+	for _, canonical := range canonicals {
+		aliases = append(aliases, canonical+"="+strings.Join(c.Labels.Aliases[canonical], ","))
+	}
+	set("label-alias", aliases...)
+	set("label-prefix", c.Labels.Prefix)
+	set("include-labels", c.Labels.Include...)
+	set("exclude-labels", c.Labels.Exclude...)
+	set("label-priority", strings.Join(c.Labels.Priority, ","))
+
+	set("stale-after", c.Thresholds.StaleAfter)
+	sizes := make([]string, len(c.Thresholds.Size))
+	for i, size := range c.Thresholds.Size {
+		sizes[i] = strconv.Itoa(size)
+	}
+	set("size-thresholds", strings.Join(sizes, ","))
+	set("fail-on", c.Thresholds.FailOn...)
+
+	setBool("business-time", c.Calendar.BusinessTime)
+	set("timezone", c.Calendar.Timezone)
+	set("working-hours", c.Calendar.WorkingHours)
+	set("weekend", strings.Join(c.Calendar.Weekend, ","))
+	set("holidays", c.Calendar.Holidays)
+
+	return flags
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/calendar"
+	"github.com/shufo/gh-pr-stats/internal/check"
+	"github.com/shufo/gh-pr-stats/internal/filter"
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/internal/labels"
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+)
+
+// Validate returns every invalid option of the configuration
+func (c Config) Validate() []error {
+	var errs []error
+	add := func(option string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", option, err))
+		}
+	}
+
+	for _, repository := range c.Repositories {
+		_, err := github.ParseRepository(repository)
+		add("repositories", err)
+	}
+	if c.Concurrency != nil && *c.Concurrency < 1 {
+		add("concurrency", fmt.Errorf("must be positive"))
+	}
+
+	if c.Format != "" {
+		_, err := render.Default.Lookup(c.Format)
+		add("format", err)
+	}
+	if len(c.Columns) > 0 {
+		_, err := utils.ParseColumns(strings.Join(c.Columns, ","))
+		add("columns", err)
+	}
+	if c.SortBy != "" {
+		_, err := utils.ParseSortBy(c.SortBy)
+		add("sort-by", err)
+	}
+	if c.Top != nil && *c.Top < 0 {
+		add("top", fmt.Errorf("must not be negative"))
+	}
+	if c.MinCount != nil && *c.MinCount < 0 {
+		add("min-count", fmt.Errorf("must not be negative"))
+	}
+	if c.GroupBy != "" && !slices.Contains(stats.GroupByOptions, c.GroupBy) {
+		add("group-by", fmt.Errorf("invalid group %q. Supported: %s", c.GroupBy, strings.Join(stats.GroupByOptions, ", ")))
+	}
+
+	if c.Filter != "" {
+		expr, err := filter.Parse(c.Filter)
+		if err == nil {
+			err = filter.ValidatePushdown(expr)
+		}
+		add("filter", err)
+	}
+	if c.Since != "" {
+		_, err := utils.ParseSince(c.Since, time.Now())
+		add("since", err)
+	}
+	if c.ExcludeBots != nil && c.OnlyBots != nil {
+		add("only-bots", filter.AuthorOptions{ExcludeBots: *c.ExcludeBots, OnlyBots: *c.OnlyBots}.Validate())
+	}
+
+	canonicals := make([]string, 0, len(c.Labels.Aliases))
+	for canonical := range c.Labels.Aliases {
+		canonicals = append(canonicals, canonical)
+	}
+	sort.Strings(canonicals)
+	for _, canonical := range canonicals {
+		if aliases := c.Labels.Aliases[canonical]; canonical == "" || len(aliases) == 0 {
+			add("labels.aliases", fmt.Errorf("invalid alias %q: expected a list of label names", canonical))
+		}
+	}
+	_, err := labels.New(labels.Options{Include: c.Labels.Include, Exclude: c.Labels.Exclude})
+	add("labels", err)
+
+	if c.Thresholds.StaleAfter != "" {
+		_, err := utils.ParsePeriod(c.Thresholds.StaleAfter)
+		add("thresholds.stale-after", err)
+	}
+	if len(c.Thresholds.Size) > 0 {
+		spec := make([]string, len(c.Thresholds.Size))
+		for i, size := range c.Thresholds.Size {
+			spec[i] = fmt.Sprint(size)
+		}
+		_, err := stats.ParseSizeThresholds(strings.Join(spec, ","))
+		add("thresholds.size", err)
+	}
+	_, err = check.ParseRules(c.Thresholds.FailOn)
+	add("thresholds.fail-on", err)
+
+	cal := calendar.Default()
+	if c.Calendar.Timezone != "" {
+		add("calendar.timezone", cal.SetTimezone(c.Calendar.Timezone))
+	}
+	if c.Calendar.WorkingHours != "" {
+		add("calendar.working-hours", cal.SetWorkingHours(c.Calendar.WorkingHours))
+	}
+	if len(c.Calendar.Weekend) > 0 {
+		add("calendar.weekend", cal.SetWeekend(strings.Join(c.Calendar.Weekend, ",")))
+	}
+	if c.Calendar.Holidays != "" {
+		if _, err := os.Stat(c.Calendar.Holidays); err != nil {
+			add("calendar.holidays", err)
+		} else {
+			add("calendar.holidays", cal.LoadHolidays(c.Calendar.Holidays))
+		}
+	}
+
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := c.Profiles[name]
+		if len(profile.Profiles) > 0 {
+			add("profiles."+name, fmt.Errorf("profiles cannot be nested"))
+		}
+		for _, err := range profile.Validate() {
+			add("profiles."+name, err)
+		}
+	}
+
+	return errs
+}
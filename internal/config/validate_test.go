@@ -0,0 +1,146 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorStrings returns the messages of the errors
+func errorStrings(errs []error) []string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return messages
+}
+
+func TestValidateAliasesOrder(t *testing.T) {
+	c, err := Parse([]byte(`
+labels:
+  aliases:
+    feature: []
+    bug: []
+    docs: [documentation]
+    chore: []
+`))
+	require.NoError(t, err)
+
+	expected := []string{
+		`labels.aliases: invalid alias "bug": expected a list of label names`,
+		`labels.aliases: invalid alias "chore": expected a list of label names`,
+		`labels.aliases: invalid alias "feature": expected a list of label names`,
+	}
+	// Map iteration order is random, so validate repeatedly
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, expected, errorStrings(c.Validate()))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name: "valid",
+			config: `
+filter: label:bug AND NOT author:renovate[bot]
+labels:
+  ignore-case: true
+  aliases:
+    bug: [defect, Bug-Report]
+  include: ["^area/", "^bug$"]
+thresholds:
+  fail-on: ["median_days_to_close > 3"]
+`,
+		},
+		{
+			name:     "filter syntax error",
+			config:   `filter: "label:bug AND"`,
+			expected: []string{"filter: "},
+		},
+		{
+			name:     "filter base term in an OR group",
+			config:   `filter: "base:main OR label:bug"`,
+			expected: []string{"filter: "},
+		},
+		{
+			name: "exclusive bot options",
+			config: `
+exclude-bots: true
+only-bots: true
+`,
+			expected: []string{"only-bots: "},
+		},
+		{
+			name: "invalid label patterns",
+			config: `
+labels:
+  include: ["[a-"]
+`,
+			expected: []string{`labels: invalid label pattern "[a-"`},
+		},
+		{
+			name: "invalid exclude pattern",
+			config: `
+labels:
+  exclude: ["^wip", "(draft"]
+`,
+			expected: []string{`labels: invalid label pattern "(draft"`},
+		},
+		{
+			name: "invalid profile",
+			config: `
+profiles:
+  bugs:
+    top: -1
+    labels:
+      include: ["[a-"]
+`,
+			expected: []string{"profiles.bugs: top: must not be negative", `profiles.bugs: labels: invalid label pattern "[a-"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse([]byte(tt.config))
+			require.NoError(t, err)
+
+			errs := errorStrings(c.Validate())
+			require.Len(t, errs, len(tt.expected), errs)
+			for i, expected := range tt.expected {
+				assert.Contains(t, errs[i], expected)
+			}
+		})
+	}
+}
+
+func TestResolveLabels(t *testing.T) {
+	user, err := Parse([]byte(`
+labels:
+  ignore-case: false
+  aliases:
+    docs: [documentation]
+    bug: [defect]
+  exclude: ["^wip"]
+`))
+	require.NoError(t, err)
+	repo, err := Parse([]byte(`
+labels:
+  ignore-case: true
+  aliases:
+    bug: [defect, Bug-Report]
+`))
+	require.NoError(t, err)
+
+	settings := Resolve([]*File{{Path: "user.yml", Config: user}, {Path: "repo.yml", Config: repo}})
+	assert.Equal(t, []Setting{
+		{Flag: "exclude-labels", Values: []string{"^wip"}, Source: "user.yml"},
+		// The aliases of the repository replace the ones of the user
+		{Flag: "label-alias", Values: []string{"bug=defect,Bug-Report"}, Source: "repo.yml"},
+		{Flag: "label-ignore-case", Values: []string{"true"}, Source: "repo.yml"},
+	}, settings)
+}
@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// AuthorOptions selects prs by their author
+type AuthorOptions struct {
+	// Exclude lists logins whose prs are dropped, case-insensitively
+	Exclude     []string
+	ExcludeBots bool
+	OnlyBots    bool
+}
+
+// Validate reports contradicting options
+func (o AuthorOptions) Validate() error {
+	if o.ExcludeBots && o.OnlyBots {
+		return fmt.Errorf("--exclude-bots and --only-bots cannot be combined")
+	}
+	return nil
+}
+
+// Authors returns the prs whose author matches the options
+func Authors(prs []types.PullRequest, opts AuthorOptions) []types.PullRequest {
+	if len(opts.Exclude) == 0 && !opts.ExcludeBots && !opts.OnlyBots {
+		return prs
+	}
+
+	excluded := make(map[string]bool, len(opts.Exclude))
+	for _, login := range opts.Exclude {
+		excluded[strings.ToLower(login)] = true
+	}
+
+	filtered := make([]types.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if excluded[strings.ToLower(pr.User.Login)] {
+			continue
+		}
+		if opts.ExcludeBots && pr.User.IsBot() {
+			continue
+		}
+		if opts.OnlyBots && !pr.User.IsBot() {
+			continue
+		}
+		filtered = append(filtered, pr)
+	}
+	return filtered
+}
@@ -0,0 +1,42 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthors(t *testing.T) {
+	prs := []types.PullRequest{
+		{Number: 1, User: types.User{Login: "octocat", Type: "User"}},
+		{Number: 2, User: types.User{Login: "dependabot[bot]", Type: "Bot"}},
+		{Number: 3, User: types.User{Login: "renovate", Type: "Bot"}},
+		{Number: 4, User: types.User{Login: "someone-bot[bot]", Type: "User"}},
+	}
+
+	tests := []struct {
+		name     string
+		opts     AuthorOptions
+		expected []int
+	}{
+		{name: "no options", expected: []int{1, 2, 3, 4}},
+		{name: "exclude authors case-insensitively", opts: AuthorOptions{Exclude: []string{"OctoCat", "renovate"}}, expected: []int{2, 4}},
+		{name: "exclude bots", opts: AuthorOptions{ExcludeBots: true}, expected: []int{1}},
+		{name: "only bots", opts: AuthorOptions{OnlyBots: true}, expected: []int{2, 3, 4}},
+		{name: "only bots without excluded", opts: AuthorOptions{OnlyBots: true, Exclude: []string{"renovate"}}, expected: []int{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var numbers []int
+			for _, pr := range Authors(prs, tt.opts) {
+				numbers = append(numbers, pr.Number)
+			}
+			assert.Equal(t, tt.expected, numbers)
+		})
+	}
+
+	assert.NoError(t, AuthorOptions{ExcludeBots: true}.Validate())
+	assert.ErrorContains(t, AuthorOptions{ExcludeBots: true, OnlyBots: true}.Validate(), "cannot be combined")
+}
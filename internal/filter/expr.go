@@ -0,0 +1,373 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Expr is a node of a parsed filter expression
+type Expr interface {
+	// Match reports whether the pr satisfies the expression
+	Match(pr types.PullRequest) bool
+	String() string
+}
+
+// And matches when both sides match
+type And struct{ Left, Right Expr }
+
+// Or matches when either side matches
+type Or struct{ Left, Right Expr }
+
+// Not matches when the inner expression does not match
+type Not struct{ Expr Expr }
+
+// Term compares a single pr field with a value
+type Term struct {
+	Field    string
+	Operator string
+	Value    string
+	// date is the parsed value of date fields
+	date time.Time
+}
+
+func (e And) Match(pr types.PullRequest) bool { return e.Left.Match(pr) && e.Right.Match(pr) }
+func (e Or) Match(pr types.PullRequest) bool  { return e.Left.Match(pr) || e.Right.Match(pr) }
+func (e Not) Match(pr types.PullRequest) bool { return !e.Expr.Match(pr) }
+
+func (e And) String() string { return fmt.Sprintf("(%s AND %s)", e.Left, e.Right) }
+func (e Or) String() string  { return fmt.Sprintf("(%s OR %s)", e.Left, e.Right) }
+func (e Not) String() string { return fmt.Sprintf("NOT %s", e.Expr) }
+func (t Term) String() string {
+	if t.Operator == ":" {
+		return fmt.Sprintf("%s:%s", t.Field, quote(t.Value))
+	}
+	return fmt.Sprintf("%s%s%s", t.Field, t.Operator, t.Value)
+}
+
+// Fields lists the supported fields
+var Fields = []string{"label", "author", "state", "is", "base", "title", "created", "updated", "closed", "merged"}
+
+var dateFields = map[string]bool{"created": true, "updated": true, "closed": true, "merged": true}
+
+// Match evaluates the term against the pr. Base branch terms are not known
+// locally and always match; they must be pushed down into the search query.
+func (t Term) Match(pr types.PullRequest) bool {
+	switch t.Field {
+	case "label":
+		for _, label := range pr.Labels {
+			if strings.EqualFold(label.Name, t.Value) {
+				return true
+			}
+		}
+		return false
+	case "author":
+		return strings.EqualFold(pr.User.Login, t.Value)
+	case "state", "is":
+		switch strings.ToLower(t.Value) {
+		case "open", "closed":
+			return pr.State == strings.ToLower(t.Value)
+		case "merged":
+			return pr.MergedAt() != nil
+		case "unmerged":
+			return pr.State == "closed" && pr.MergedAt() == nil
+		case "bot":
+			return pr.User.IsBot()
+		}
+		return false
+	case "base":
+		return true
+	case "title":
+		return strings.Contains(strings.ToLower(pr.Title), strings.ToLower(t.Value))
+	}
+
+	var value *time.Time
+	switch t.Field {
+	case "created":
+		value = pr.CreatedAt
+	case "updated":
+		value = pr.UpdatedAt
+	case "closed":
+		value = pr.ClosedAt
+	case "merged":
+		value = pr.MergedAt()
+	}
+	if value == nil {
+		return false
+	}
+	switch t.Operator {
+	case ">":
+		return value.After(t.date)
+	case ">=":
+		return !value.Before(t.date)
+	case "<":
+		return value.Before(t.date)
+	case "<=":
+		return !value.After(t.date)
+	default:
+		// A plain date matches the whole day
+		return !value.Before(t.date) && value.Before(t.date.AddDate(0, 0, 1))
+	}
+}
+
+// SyntaxError reports an invalid filter expression
+type SyntaxError struct {
+	Expression string
+	Pos        int
+	Msg        string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s\n  %s\n  %s^", e.Pos+1, e.Msg, e.Expression, strings.Repeat(" ", e.Pos))
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+	tokenEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		switch c := input[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		default:
+			start := i
+			var word strings.Builder
+			for i < len(input) && !strings.ContainsRune(" \t\n()", rune(input[i])) {
+				if input[i] == '"' {
+					end := strings.IndexByte(input[i+1:], '"')
+					if end < 0 {
+						return nil, &SyntaxError{input, i, "unterminated quote"}
+					}
+					word.WriteString(input[i+1 : i+1+end])
+					i += end + 2
+					continue
+				}
+				word.WriteByte(input[i])
+				i++
+			}
+
+			text := word.String()
+			kind := tokenWord
+			switch input[start:i] {
+			case "AND":
+				kind = tokenAnd
+			case "OR":
+				kind = tokenOr
+			case "NOT":
+				kind = tokenNot
+			}
+			tokens = append(tokens, token{kind, text, start})
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(input)}), nil
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *parser) errorf(t token, format string, a ...interface{}) error {
+	return &SyntaxError{p.input, t.pos, fmt.Sprintf(format, a...)}
+}
+
+// Parse parses a filter expression such as
+// `label:bug AND NOT author:renovate[bot] AND base:main AND created>=2024-01-01`.
+// Terms next to each other without an operator are combined with AND.
+func Parse(input string) (Expr, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{input: input, tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorf(p.peek(), "empty expression")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return expr, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenWord, tokenNot, tokenLParen:
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNot:
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{expr}, nil
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\"")
+		}
+		return expr, nil
+	case tokenWord:
+		if strings.HasPrefix(t.text, "-") {
+			term, err := p.parseTerm(token{t.kind, t.text[1:], t.pos + 1})
+			if err != nil {
+				return nil, err
+			}
+			return Not{term}, nil
+		}
+		return p.parseTerm(t)
+	case tokenEOF:
+		return nil, p.errorf(t, "unexpected end of expression")
+	default:
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+}
+
+var termPattern = regexp.MustCompile(`^([a-z]+)(:>=|:<=|:>|:<|>=|<=|>|<|=|:)(.*)$`)
+
+func (p *parser) parseTerm(t token) (Expr, error) {
+	m := termPattern.FindStringSubmatch(t.text)
+	if m == nil {
+		return nil, p.errorf(t, "expected field:value, got %q", t.text)
+	}
+
+	field, operator, value := m[1], strings.TrimPrefix(m[2], ":"), m[3]
+	if operator == "" || operator == "=" {
+		operator = ":"
+	}
+	if value == "" {
+		return nil, p.errorf(t, "missing value for %s", field)
+	}
+
+	known := false
+	for _, f := range Fields {
+		known = known || f == field
+	}
+	if !known {
+		return nil, p.errorf(t, "unknown field %q. Supported fields: %s", field, strings.Join(Fields, ", "))
+	}
+
+	term := Term{Field: field, Operator: operator, Value: value}
+	if dateFields[field] {
+		date, err := parseDate(value)
+		if err != nil {
+			return nil, p.errorf(t, "invalid date %q. Expected YYYY-MM-DD or RFC 3339", value)
+		}
+		term.date = date
+	} else if operator != ":" {
+		return nil, p.errorf(t, "operator %s is only supported for dates", operator)
+	}
+	if field == "state" || field == "is" {
+		switch strings.ToLower(value) {
+		case "open", "closed", "merged", "unmerged", "bot":
+		default:
+			return nil, p.errorf(t, "invalid %s %q. Expected open, closed, merged, unmerged or bot", field, value)
+		}
+	}
+
+	return term, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func quote(value string) string {
+	if strings.ContainsAny(value, " \t()") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// Apply returns the prs matching the expression
+func Apply(prs []types.PullRequest, expr Expr) []types.PullRequest {
+	if expr == nil {
+		return prs
+	}
+	filtered := make([]types.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if expr.Match(pr) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
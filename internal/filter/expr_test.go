@@ -0,0 +1,115 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "label:bug", expected: "label:bug"},
+		{input: "label=bug", expected: "label:bug"},
+		{input: `title:"flaky test"`, expected: `title:"flaky test"`},
+		{input: "label:bug author:octocat", expected: "(label:bug AND author:octocat)"},
+		{input: "label:bug OR label:regression AND -is:bot", expected: "(label:bug OR (label:regression AND NOT is:bot))"},
+		{input: "NOT (label:bug OR label:docs)", expected: "NOT (label:bug OR label:docs)"},
+		{input: "created>=2024-01-01 merged:<2024-02-01T10:00:00Z", expected: "(created>=2024-01-01 AND merged<2024-02-01T10:00:00Z)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr.String())
+		})
+	}
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{input: "", pos: 0, msg: "empty expression"},
+		{input: "label:bug AND", pos: 13, msg: "unexpected end of expression"},
+		{input: "(label:bug", pos: 10, msg: `expected ")"`},
+		{input: "label:bug)", pos: 9, msg: `unexpected ")"`},
+		{input: `title:"flaky`, pos: 6, msg: "unterminated quote"},
+		{input: "bug", pos: 0, msg: `expected field:value, got "bug"`},
+		{input: "label:", pos: 0, msg: "missing value for label"},
+		{input: "milestone:v1", pos: 0, msg: `unknown field "milestone"`},
+		{input: "created>=yesterday", pos: 0, msg: `invalid date "yesterday"`},
+		{input: "label>bug", pos: 0, msg: "operator > is only supported for dates"},
+		{input: "label:bug is:draft", pos: 10, msg: `invalid is "draft"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.pos, syntaxErr.Pos)
+			assert.Contains(t, syntaxErr.Msg, tt.msg)
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	created := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	closed := created.Add(48 * time.Hour)
+	merged := types.PullRequest{
+		Title:       "Fix flaky test",
+		State:       "closed",
+		User:        types.User{Login: "Octocat", Type: "User"},
+		Labels:      []types.Label{{Name: "Bug"}},
+		CreatedAt:   &created,
+		ClosedAt:    &closed,
+		PullRequest: &types.PullRequestRef{MergedAt: &closed},
+	}
+	open := types.PullRequest{
+		Title:     "Bump deps",
+		State:     "open",
+		User:      types.User{Login: "renovate[bot]", Type: "Bot"},
+		CreatedAt: &created,
+	}
+
+	tests := []struct {
+		input   string
+		matches []bool
+	}{
+		{input: "label:bug", matches: []bool{true, false}},
+		{input: "author:octocat", matches: []bool{true, false}},
+		{input: "is:merged", matches: []bool{true, false}},
+		{input: "is:unmerged", matches: []bool{false, false}},
+		{input: "is:bot", matches: []bool{false, true}},
+		{input: "state:open", matches: []bool{false, true}},
+		{input: "title:FLAKY", matches: []bool{true, false}},
+		{input: "created:2024-03-10", matches: []bool{true, true}},
+		{input: "created<2024-03-10", matches: []bool{false, false}},
+		{input: "closed>=2024-03-12", matches: []bool{true, false}},
+		// Base terms are only known to the search API
+		{input: "base:main", matches: []bool{true, true}},
+		{input: "NOT is:bot OR label:bug", matches: []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, []bool{expr.Match(merged), expr.Match(open)})
+		})
+	}
+
+	expr, err := Parse("is:bot")
+	require.NoError(t, err)
+	assert.Equal(t, []types.PullRequest{open}, Apply([]types.PullRequest{merged, open}, expr))
+	assert.Len(t, Apply([]types.PullRequest{merged, open}, nil), 2)
+}
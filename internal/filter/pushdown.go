@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// LocalOnly is evaluated locally only and never narrows the search query,
+// e.g. for windows whose search could exceed the search result limit
+type LocalOnly struct{ Expr Expr }
+
+func (e LocalOnly) Match(pr types.PullRequest) bool { return e.Expr.Match(pr) }
+func (e LocalOnly) String() string                  { return e.Expr.String() }
+
+// conjuncts flattens the top-level AND chain of the expression
+func conjuncts(expr Expr) []Expr {
+	if and, ok := expr.(And); ok {
+		return append(conjuncts(and.Left), conjuncts(and.Right)...)
+	}
+	return []Expr{expr}
+}
+
+// simpleTerm returns the term of a possibly negated term
+func simpleTerm(expr Expr) (Term, bool, bool) {
+	switch e := expr.(type) {
+	case Term:
+		return e, false, true
+	case Not:
+		if term, ok := e.Expr.(Term); ok {
+			return term, true, true
+		}
+	}
+	return Term{}, false, false
+}
+
+// SearchQualifiers returns the GitHub search qualifiers equivalent to the
+// terms of the top-level AND chain that the search API supports. The
+// expression must still be applied locally with Local.
+func SearchQualifiers(expr Expr) []string {
+	if expr == nil {
+		return nil
+	}
+
+	var qualifiers []string
+	for _, conjunct := range conjuncts(expr) {
+		term, negated, ok := simpleTerm(conjunct)
+		if !ok {
+			continue
+		}
+
+		qualifier := ""
+		switch term.Field {
+		case "label", "author", "base":
+			qualifier = fmt.Sprintf("%s:%s", term.Field, searchValue(term.Value))
+		case "state", "is":
+			if value := strings.ToLower(term.Value); value != "bot" {
+				qualifier = "is:" + value
+			}
+		case "created", "updated", "closed", "merged":
+			operator := term.Operator
+			if operator == ":" {
+				operator = ""
+			}
+			qualifier = fmt.Sprintf("%s:%s%s", term.Field, operator, term.Value)
+		}
+		if qualifier == "" {
+			continue
+		}
+		if negated {
+			qualifier = "-" + qualifier
+		}
+		qualifiers = append(qualifiers, qualifier)
+	}
+	return qualifiers
+}
+
+func searchValue(value string) string {
+	if strings.ContainsAny(value, " \t\"") {
+		return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+	}
+	return value
+}
+
+// Local returns the expression without the terms that can only be evaluated
+// by the search API, or nil when nothing is left to evaluate locally
+func Local(expr Expr) Expr {
+	if expr == nil {
+		return nil
+	}
+
+	var local Expr
+	for _, conjunct := range conjuncts(expr) {
+		if term, _, ok := simpleTerm(conjunct); ok && term.Field == "base" {
+			continue
+		}
+		if local == nil {
+			local = conjunct
+		} else {
+			local = And{local, conjunct}
+		}
+	}
+	return local
+}
+
+// RequiresSearch reports whether the expression has terms only the search API can evaluate
+func RequiresSearch(expr Expr) bool {
+	return expr != nil && countBaseTerms(expr) > 0
+}
+
+// ValidatePushdown reports base branch terms that cannot be pushed down into
+// the search query because they are nested in OR or NOT groups
+func ValidatePushdown(expr Expr) error {
+	if expr == nil {
+		return nil
+	}
+
+	pushed := 0
+	for _, conjunct := range conjuncts(expr) {
+		if term, _, ok := simpleTerm(conjunct); ok && term.Field == "base" {
+			pushed++
+		}
+	}
+	if pushed != countBaseTerms(expr) {
+		return fmt.Errorf("invalid filter: base can only be combined with AND, not nested in OR or NOT groups")
+	}
+	return nil
+}
+
+func countBaseTerms(expr Expr) int {
+	switch e := expr.(type) {
+	case And:
+		return countBaseTerms(e.Left) + countBaseTerms(e.Right)
+	case Or:
+		return countBaseTerms(e.Left) + countBaseTerms(e.Right)
+	case Not:
+		return countBaseTerms(e.Expr)
+	case Term:
+		if e.Field == "base" {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchQualifiers(t *testing.T) {
+	tests := []struct {
+		input      string
+		qualifiers []string
+		local      string
+	}{
+		{
+			input:      `label:bug AND NOT author:renovate base:main title:"flaky test"`,
+			qualifiers: []string{"label:bug", "-author:renovate", "base:main"},
+			local:      `((label:bug AND NOT author:renovate) AND title:"flaky test")`,
+		},
+		{
+			input:      `label:"help wanted" state:closed is:bot created>=2024-01-01 merged:2024-02-01`,
+			qualifiers: []string{`label:"help wanted"`, "is:closed", "created:>=2024-01-01", "merged:2024-02-01"},
+			local:      `((((label:"help wanted" AND state:closed) AND is:bot) AND created>=2024-01-01) AND merged:2024-02-01)`,
+		},
+		// Terms in OR groups are evaluated locally only
+		{
+			input: "label:bug OR label:docs",
+			local: "(label:bug OR label:docs)",
+		},
+		{
+			input:      "(label:bug OR label:docs) -is:open",
+			qualifiers: []string{"-is:open"},
+			local:      "((label:bug OR label:docs) AND NOT is:open)",
+		},
+		// Base terms are only evaluated by the search
+		{
+			input:      "base:main",
+			qualifiers: []string{"base:main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.qualifiers, SearchQualifiers(expr))
+
+			local := Local(expr)
+			if tt.local == "" {
+				assert.Nil(t, local)
+				return
+			}
+			require.NotNil(t, local)
+			assert.Equal(t, tt.local, local.String())
+		})
+	}
+
+	assert.Nil(t, SearchQualifiers(nil))
+	assert.Nil(t, Local(nil))
+}
+
+func TestLocalOnly(t *testing.T) {
+	created, err := Parse("created>=2024-01-01")
+	require.NoError(t, err)
+	label, err := Parse("label:bug")
+	require.NoError(t, err)
+
+	// The window never narrows the search, but is still evaluated locally
+	expr := And{LocalOnly{Expr: created}, label}
+	assert.Equal(t, []string{"label:bug"}, SearchQualifiers(expr))
+	assert.Equal(t, "(created>=2024-01-01 AND label:bug)", Local(expr).String())
+	assert.False(t, RequiresSearch(expr))
+}
+
+func TestValidatePushdown(t *testing.T) {
+	tests := []struct {
+		input          string
+		valid          bool
+		requiresSearch bool
+	}{
+		{input: "label:bug", valid: true},
+		{input: "label:bug base:main", valid: true, requiresSearch: true},
+		{input: "-base:main", valid: true, requiresSearch: true},
+		{input: "base:main OR label:bug", requiresSearch: true},
+		{input: "NOT (base:main label:bug)", requiresSearch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			require.NoError(t, err)
+			if tt.valid {
+				assert.NoError(t, ValidatePushdown(expr))
+			} else {
+				assert.ErrorContains(t, ValidatePushdown(expr), "base can only be combined with AND")
+			}
+			assert.Equal(t, tt.requiresSearch, RequiresSearch(expr))
+		})
+	}
+}
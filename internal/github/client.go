@@ -2,54 +2,200 @@ package github
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/cli/go-gh/v2"
 	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/shufo/gh-pr-stats/internal/utils"
 	"github.com/shufo/gh-pr-stats/pkg/types"
+	"golang.org/x/exp/slog"
 )
 
-var debug bool
+// ClientOptions configures a Client
+type ClientOptions struct {
+	// Host is the default GitHub host, by default GH_HOST or the host gh is
+	// authenticated with. Repositories prefixed with another host are fetched
+	// from that host.
+	Host string
+	// AuthToken is the token of the default host, by default the token gh is
+	// authenticated with. Other hosts always use the token of gh.
+	AuthToken string
+	// Remote is the git remote the repository of the current directory is
+	// taken from when a method is given no repository, by default upstream,
+	// github, origin or the first remote
+	Remote string
+	// Concurrency is the number of parallel requests fetching pr details
+	Concurrency int
+	// CacheTTL caches the API responses for this duration when positive
+	CacheTTL time.Duration
+	// CacheDir is the directory of the cached responses
+	CacheDir string
+	// Progress shows a spinner while fetching
+	Progress bool
+	// Logger receives the debug output, none when nil
+	Logger *slog.Logger
+	// Transport sends the API requests, the default transport when nil
+	Transport http.RoundTripper
+}
 
-func SetDebug(d bool) {
-	debug = d
+// Client fetches prs and their details from the GitHub REST API of one
+// or more hosts
+type Client struct {
+	rest        *api.RESTClient
+	host        string
+	opts        ClientOptions
+	mu          sync.Mutex
+	hosts       map[string]*api.RESTClient
+	concurrency int
+	progress    *utils.Progress
 }
 
-func GetRepoInfo() (string, error) {
-	stdOut, stdErr, err := gh.Exec("repo", "view", "--json", "nameWithOwner", "-q", ".nameWithOwner")
+// NewClient creates a client. Unset options are taken from the gh configuration.
+func NewClient(opts ClientOptions) (*Client, error) {
+	host := DefaultHost(opts.Host)
+
+	rest, err := newRESTClient(host, opts.AuthToken, opts)
 	if err != nil {
-		return "", fmt.Errorf("%v", stdErr.String())
+		return nil, fmt.Errorf("failed to create GitHub client: %v", err)
 	}
-	return stdOut.String()[:stdOut.Len()-1], nil
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Client{
+		rest:        rest,
+		host:        host,
+		opts:        opts,
+		hosts:       make(map[string]*api.RESTClient),
+		concurrency: concurrency,
+		progress:    &utils.Progress{Logger: opts.Logger, Spinner: opts.Progress},
+	}, nil
 }
 
-// FetchPullRequestsFunc is a function type for fetching prs
-type FetchPullRequestsFunc func(string) ([]types.PullRequest, error)
+func newRESTClient(host, token string, opts ClientOptions) (*api.RESTClient, error) {
+	return api.NewRESTClient(api.ClientOptions{
+		Host:        host,
+		AuthToken:   token,
+		EnableCache: opts.CacheTTL > 0,
+		CacheTTL:    opts.CacheTTL,
+		CacheDir:    opts.CacheDir,
+		Transport:   opts.Transport,
+	})
+}
 
-// DefaultFetchPullRequests is the actual implementation
-func DefaultFetchPullRequests(repository string) ([]types.PullRequest, error) {
-	client, err := api.DefaultRESTClient()
+// restFor returns the REST client of the host of the repository and the
+// owner/repo name of the repository. An empty repository is the one of the
+// git remotes of the current directory.
+func (c *Client) restFor(repository string) (*api.RESTClient, string, error) {
+	repository, err := ResolveRepository(repository, c.opts.Remote)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub client: %v", err)
+		return nil, "", err
+	}
+	repo, err := ParseRepository(repository)
+	if err != nil {
+		return nil, "", err
+	}
+	if repo.Host == "" || auth.NormalizeHostname(repo.Host) == c.host {
+		return c.rest, repo.FullName(), nil
 	}
 
-	if repository == "" {
-		currentRepo, err := GetRepoInfo()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get current repository: %w", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	host := auth.NormalizeHostname(repo.Host)
+	rest, ok := c.hosts[host]
+	if !ok {
+		// The token of the host is taken from GH_ENTERPRISE_TOKEN or gh
+		if rest, err = newRESTClient(host, "", c.opts); err != nil {
+			return nil, "", fmt.Errorf("failed to create GitHub client for %s: %v", host, err)
+		}
+		c.debugf("created client of host %s", host)
+		c.hosts[host] = rest
+	}
+	return rest, repo.FullName(), nil
+}
+
+func (c *Client) debugf(format string, a ...interface{}) {
+	c.progress.Debugf(format, a...)
+}
+
+func (c *Client) startProgress(suffix string) {
+	c.progress.Start(suffix)
+}
+
+func (c *Client) updateProgress(suffix string) {
+	c.progress.Update(suffix)
+}
+
+func (c *Client) stopProgress() {
+	c.progress.Stop()
+}
+
+// forEach calls fn for every pr, running up to the configured concurrency
+// of calls in parallel, and returns the first error
+func (c *Client) forEach(prs []types.PullRequest, progress string, fn func(types.PullRequest) error) error {
+	var (
+		mu       sync.Mutex
+		done     int
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	queue := make(chan types.PullRequest)
+
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pr := range queue {
+				err := fn(pr)
+
+				mu.Lock()
+				done++
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				c.debugf("%s #%d (%d/%d)", strings.ToLower(progress), pr.Number, done, len(prs))
+				c.updateProgress(fmt.Sprintf(" %s... (%d/%d)", progress, done, len(prs)))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, pr := range prs {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
 		}
-		repository = currentRepo
+		queue <- pr
+	}
+	close(queue)
+	wg.Wait()
+
+	return firstErr
+}
+
+// FetchPullRequests fetches every pr of the repository
+func (c *Client) FetchPullRequests(repository string) ([]types.PullRequest, error) {
+	rest, name, err := c.restFor(repository)
+	if err != nil {
+		return nil, err
 	}
 
 	// First, get the total count of prs to calculate pages
 	var totalCount int
 	// path := fmt.Sprintf("repos/%s/prs?state=all&per_page=1", repo)
-	path := fmt.Sprintf("search/issues?q=repo:%s", repository)
+	path := fmt.Sprintf("search/issues?q=repo:%s", name)
 	response := struct {
 		TotalCount int `json:"total_count"`
 	}{}
 
-	err = client.Get(path, &response)
+	err = rest.Get(path, &response)
 	if err == nil {
 		totalCount = response.TotalCount
 	}
@@ -59,28 +205,25 @@ func DefaultFetchPullRequests(repository string) ([]types.PullRequest, error) {
 	totalPages := (totalCount + perPage - 1) / perPage
 
 	if totalPages > 0 {
-		utils.DebugPrintf("Total prs (including PRs): %d", totalCount)
-		utils.DebugPrintf("Total pages: %d\n", totalPages)
+		c.debugf("Total prs (including PRs): %d", totalCount)
+		c.debugf("Total pages: %d\n", totalPages)
 	}
 
-	utils.StartSpinner(" Fetching prs...")
+	c.startProgress(" Fetching prs...")
 
 	var allPullRequests []types.PullRequest
 
-	utils.DebugPrintf("starting to fetch pull requests")
+	c.debugf("starting to fetch pull requests")
 
 	for page := 1; page <= totalPages; page++ {
-		if debug {
-			utils.DebugPrintf("fetching pull requests (%d/%d)", page, totalPages)
-		} else {
-			utils.UpdateSpinnerSuffix(fmt.Sprintf(" Fetching pull requests... (%d/%d)", page, totalPages))
-		}
+		c.debugf("fetching pull requests (%d/%d)", page, totalPages)
+		c.updateProgress(fmt.Sprintf(" Fetching pull requests... (%d/%d)", page, totalPages))
 
 		var pagePullRequests []types.PullRequest
-		path := fmt.Sprintf("repos/%s/issues?state=all&per_page=%d&page=%d", repository, perPage, page)
-		err := client.Get(path, &pagePullRequests)
+		path := fmt.Sprintf("repos/%s/issues?state=all&per_page=%d&page=%d", name, perPage, page)
+		err := rest.Get(path, &pagePullRequests)
 		if err != nil {
-			utils.StopSpinner()
+			c.stopProgress()
 			return nil, fmt.Errorf("failed to fetch prs: %v", err)
 		}
 
@@ -96,30 +239,190 @@ func DefaultFetchPullRequests(repository string) ([]types.PullRequest, error) {
 				prsCount++
 			}
 		}
-		utils.DebugPrintf("fetched %d: found %d prs (total so far: %d)",
+		c.debugf("fetched %d: found %d prs (total so far: %d)",
 			page, prsCount, len(allPullRequests))
 	}
 
 	// Stop spinner and clear the line
-	if !debug {
-		utils.StopSpinner()
-	}
+	c.stopProgress()
 
-	utils.DebugPrintf("finished fetching prs (total: %d)", len(allPullRequests))
+	c.debugf("finished fetching prs (total: %d)", len(allPullRequests))
 	return allPullRequests, nil
 }
 
-// fetchPullRequests is the package variable that can be swapped in tests
-var fetchPullRequests FetchPullRequestsFunc = DefaultFetchPullRequests
+// SyncPullRequests fetches the prs updated since the given time, or every pr
+// when since is nil. Unlike FetchPullRequests it does not drive the spinner,
+// so it is safe to call from long-running modes.
+func (c *Client) SyncPullRequests(repository string, since *time.Time) ([]types.PullRequest, error) {
+	rest, name, err := c.restFor(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage := 100
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("per_page", fmt.Sprintf("%d", perPage))
+	if since != nil {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+
+	var prs []types.PullRequest
+	for page := 1; ; page++ {
+		query.Set("page", fmt.Sprintf("%d", page))
+		c.debugf("syncing pull requests of %s (page %d)", name, page)
+
+		var pagePullRequests []types.PullRequest
+		path := fmt.Sprintf("repos/%s/issues?%s", name, query.Encode())
+		if err := rest.Get(path, &pagePullRequests); err != nil {
+			return nil, fmt.Errorf("failed to fetch prs: %v", err)
+		}
+
+		for _, pr := range pagePullRequests {
+			if pr.PullRequest != nil {
+				prs = append(prs, pr)
+			}
+		}
+
+		if len(pagePullRequests) < perPage {
+			break
+		}
+	}
+
+	c.debugf("synced %d prs of %s", len(prs), name)
+	return prs, nil
+}
+
+// FetchReviews fetches the reviews of every given pr, keyed by pr number
+func (c *Client) FetchReviews(repository string, prs []types.PullRequest) (map[int][]types.Review, error) {
+	rest, name, err := c.restFor(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	c.startProgress(" Fetching reviews...")
+	defer c.stopProgress()
+
+	var mu sync.Mutex
+	reviews := make(map[int][]types.Review, len(prs))
+	err = c.forEach(prs, "Fetching reviews", func(pr types.PullRequest) error {
+		perPage := 100
+		var prReviews []types.Review
+		for page := 1; ; page++ {
+			var pageReviews []types.Review
+			path := fmt.Sprintf("repos/%s/pulls/%d/reviews?per_page=%d&page=%d", name, pr.Number, perPage, page)
+			if err := rest.Get(path, &pageReviews); err != nil {
+				return fmt.Errorf("failed to fetch reviews of #%d: %v", pr.Number, err)
+			}
+
+			prReviews = append(prReviews, pageReviews...)
+			if len(pageReviews) < perPage {
+				break
+			}
+		}
+
+		mu.Lock()
+		reviews[pr.Number] = prReviews
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.debugf("finished fetching reviews of %d prs", len(prs))
+	return reviews, nil
+}
+
+// FetchSizes fetches the size of every given pr, keyed by pr number
+func (c *Client) FetchSizes(repository string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+	rest, name, err := c.restFor(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	c.startProgress(" Fetching pr sizes...")
+	defer c.stopProgress()
+
+	var mu sync.Mutex
+	sizes := make(map[int]types.PullRequestSize, len(prs))
+	err = c.forEach(prs, "Fetching pr sizes", func(pr types.PullRequest) error {
+		var size types.PullRequestSize
+		path := fmt.Sprintf("repos/%s/pulls/%d", name, pr.Number)
+		if err := rest.Get(path, &size); err != nil {
+			return fmt.Errorf("failed to fetch size of #%d: %v", pr.Number, err)
+		}
+
+		mu.Lock()
+		sizes[pr.Number] = size
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.debugf("finished fetching sizes of %d prs", len(prs))
+	return sizes, nil
+}
+
+// searchResultLimit is the maximum number of results the search API returns
+const searchResultLimit = 1000
+
+// SearchLimitError reports a search matching more prs than the search API
+// returns. No prs are returned rather than a truncated list.
+type SearchLimitError struct {
+	Total int
+}
 
-// FetchPullRequests is the public function that uses the variable
-func FetchPullRequests(repository string) ([]types.PullRequest, error) {
-	return fetchPullRequests(repository)
+func (e *SearchLimitError) Error() string {
+	return fmt.Sprintf("search matched %d prs, more than the %d the search API returns", e.Total, searchResultLimit)
 }
 
-// SetFetchPullRequestsFunc allows replacing the fetch function for testing
-func SetFetchPullRequestsFunc(f FetchPullRequestsFunc) FetchPullRequestsFunc {
-	old := fetchPullRequests
-	fetchPullRequests = f
-	return old
+// SearchPullRequests fetches the prs of the repository matching the search
+// qualifiers. A *SearchLimitError is returned when the search matches more
+// than 1000 prs.
+func (c *Client) SearchPullRequests(repository string, qualifiers []string) ([]types.PullRequest, error) {
+	rest, name, err := c.restFor(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.Join(append([]string{"repo:" + name, "is:pr"}, qualifiers...), " ")
+	c.debugf("searching pull requests: %s", query)
+
+	c.startProgress(" Searching pull requests...")
+
+	perPage := 100
+	var allPullRequests []types.PullRequest
+	for page := 1; page*perPage <= searchResultLimit; page++ {
+		response := struct {
+			TotalCount int                 `json:"total_count"`
+			Items      []types.PullRequest `json:"items"`
+		}{}
+
+		path := fmt.Sprintf("search/issues?q=%s&per_page=%d&page=%d", url.QueryEscape(query), perPage, page)
+		if err := rest.Get(path, &response); err != nil {
+			c.stopProgress()
+			return nil, fmt.Errorf("failed to search prs: %v", err)
+		}
+
+		if response.TotalCount > searchResultLimit {
+			c.stopProgress()
+			return nil, &SearchLimitError{Total: response.TotalCount}
+		}
+
+		allPullRequests = append(allPullRequests, response.Items...)
+		c.debugf("fetched page %d (total so far: %d/%d)", page, len(allPullRequests), response.TotalCount)
+		c.updateProgress(fmt.Sprintf(" Searching pull requests... (%d/%d)", len(allPullRequests), response.TotalCount))
+
+		if len(response.Items) < perPage {
+			break
+		}
+	}
+
+	c.stopProgress()
+
+	c.debugf("finished searching prs (total: %d)", len(allPullRequests))
+	return allPullRequests, nil
 }
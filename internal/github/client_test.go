@@ -0,0 +1,157 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// handlerTransport serves the API requests with a handler
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, r)
+	return rec.Result(), nil
+}
+
+// newTestClient creates a client of github.com sending the requests to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	// Enterprise hosts take their token from the environment
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+	client, err := NewClient(ClientOptions{Host: "github.com", AuthToken: "token", Transport: handlerTransport{handler}})
+	assert.NoError(t, err)
+	return client
+}
+
+// writeJSON writes v as the JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestSyncPullRequestsHosts(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Host+r.URL.Path)
+		writeJSON(w, []map[string]interface{}{
+			{"number": 1, "pull_request": map[string]interface{}{}},
+			{"number": 2},
+		})
+	})
+
+	for _, repository := range []string{"owner/repo", "https://github.com/owner/repo", "ghe.example.com/team/service"} {
+		prs, err := client.SyncPullRequests(repository, nil)
+		assert.NoError(t, err)
+		assert.Len(t, prs, 1)
+	}
+	assert.Equal(t, []string{
+		"api.github.com/repos/owner/repo/issues",
+		"api.github.com/repos/owner/repo/issues",
+		"ghe.example.com/api/v3/repos/team/service/issues",
+	}, requests)
+}
+
+func TestFetchReviewsPages(t *testing.T) {
+	var pages []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+
+		count := 100
+		if page == "2" {
+			count = 30
+		}
+		reviews := make([]map[string]interface{}, count)
+		for i := range reviews {
+			reviews[i] = map[string]interface{}{"id": len(pages)*1000 + i, "state": "COMMENTED"}
+		}
+		writeJSON(w, reviews)
+	})
+
+	reviews, err := client.FetchReviews("owner/repo", []types.PullRequest{{Number: 7}})
+	assert.NoError(t, err)
+	assert.Len(t, reviews[7], 130)
+	assert.Equal(t, []string{"1", "2"}, pages)
+}
+
+func TestSearchPullRequestsLimit(t *testing.T) {
+	total := 1500
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/search/issues", r.URL.Path)
+		assert.Equal(t, "repo:owner/repo is:pr label:bug", r.URL.Query().Get("q"))
+		writeJSON(w, map[string]interface{}{
+			"total_count": total,
+			"items":       []map[string]interface{}{{"number": 1, "pull_request": map[string]interface{}{}}},
+		})
+	})
+
+	prs, err := client.SearchPullRequests("owner/repo", []string{"label:bug"})
+	var limitErr *SearchLimitError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 1500, limitErr.Total)
+	assert.Nil(t, prs)
+	assert.Equal(t, 1, requests)
+
+	total = 1
+	prs, err = client.SearchPullRequests("owner/repo", []string{"label:bug"})
+	assert.NoError(t, err)
+	assert.Len(t, prs, 1)
+}
+
+func TestResolveEmptyRepository(t *testing.T) {
+	var paths []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/issues") || strings.HasSuffix(r.URL.Path, "/reviews") {
+			writeJSON(w, []map[string]interface{}{})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"additions": 1})
+	}
+
+	// Every method resolves an empty repository from the git remotes
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", "https://github.com/owner/repo.git"},
+		{"remote", "add", "fork", "https://github.com/me/repo.git"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		assert.NoError(t, cmd.Run())
+	}
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(cwd) })
+	t.Setenv("GH_REPO", "")
+
+	client, err := NewClient(ClientOptions{Host: "github.com", AuthToken: "token", Remote: "fork", Transport: handlerTransport{http.HandlerFunc(handler)}})
+	assert.NoError(t, err)
+
+	prs := []types.PullRequest{{Number: 7}}
+	_, err = client.SyncPullRequests("", nil)
+	assert.NoError(t, err)
+	_, err = client.FetchReviews("", prs)
+	assert.NoError(t, err)
+	_, err = client.FetchSizes("", prs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/repos/me/repo/issues",
+		"/repos/me/repo/pulls/7/reviews",
+		"/repos/me/repo/pulls/7",
+	}, paths)
+}
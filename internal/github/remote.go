@@ -0,0 +1,113 @@
+package github
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/ssh"
+)
+
+// remotePriority are the remotes used first when no remote is selected,
+// in the order of gh
+var remotePriority = []string{"upstream", "github", "origin"}
+
+// Remote is a git remote of the repository in the current directory
+type Remote struct {
+	Name string
+	// URL is the fetch URL of the remote
+	URL string
+}
+
+// Remotes returns the git remotes of the repository in the current directory
+func Remotes() ([]Remote, error) {
+	out, err := exec.Command("git", "remote", "-v").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list git remotes: %v", err)
+	}
+	return parseRemotes(string(out)), nil
+}
+
+// parseRemotes parses the output of git remote -v
+func parseRemotes(output string) []Remote {
+	var remotes []Remote
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			remotes = append(remotes, Remote{Name: fields[0], URL: fields[1]})
+		}
+	}
+	return remotes
+}
+
+// CurrentRepository returns the repository of the named git remote of the
+// current directory. Without a name, the repository of GH_REPO, or of the
+// upstream, github or origin remote, in that order, or of the first
+// remote is returned.
+func CurrentRepository(remote string) (Repository, error) {
+	if override := os.Getenv("GH_REPO"); override != "" && remote == "" {
+		return ParseRepository(override)
+	}
+
+	remotes, err := Remotes()
+	if err != nil {
+		return Repository{}, err
+	}
+	return selectRemote(remotes, remote, ssh.NewTranslator())
+}
+
+// selectRemote returns the repository of the named remote, or of the first
+// remote by priority pointing to a repository when name is empty
+func selectRemote(remotes []Remote, name string, translator *ssh.Translator) (Repository, error) {
+	if len(remotes) == 0 {
+		return Repository{}, fmt.Errorf("no git remotes are configured")
+	}
+
+	names := make([]string, len(remotes))
+	for i, remote := range remotes {
+		names[i] = remote.Name
+	}
+
+	if name != "" {
+		i := slices.Index(names, name)
+		if i < 0 {
+			return Repository{}, fmt.Errorf("git remote %q not found. Remotes: %s", name, strings.Join(names, ", "))
+		}
+		return remoteRepository(remotes[i], translator)
+	}
+
+	ordered := slices.Clone(remotes)
+	slices.SortStableFunc(ordered, func(a, b Remote) int {
+		return remoteRank(a.Name) - remoteRank(b.Name)
+	})
+	for _, remote := range ordered {
+		if repo, err := remoteRepository(remote, translator); err == nil {
+			return repo, nil
+		}
+	}
+	return Repository{}, fmt.Errorf("none of the git remotes %s points to a repository", strings.Join(names, ", "))
+}
+
+// remoteRank returns the position of the remote in remotePriority, after
+// every prioritized remote when it has none
+func remoteRank(name string) int {
+	if i := slices.Index(remotePriority, strings.ToLower(name)); i >= 0 {
+		return i
+	}
+	return len(remotePriority)
+}
+
+// remoteRepository returns the repository of the remote, resolving ssh
+// host aliases when translator is not nil
+func remoteRepository(remote Remote, translator *ssh.Translator) (Repository, error) {
+	u, err := parseURL(remote.URL)
+	if err != nil {
+		return Repository{}, fmt.Errorf("invalid URL of git remote %s: %v", remote.Name, err)
+	}
+	if translator != nil {
+		u = translator.Translate(u)
+	}
+	return repositoryFromURL(u)
+}
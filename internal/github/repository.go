@@ -0,0 +1,130 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/auth"
+)
+
+// DefaultHost returns the normalized host, or GH_HOST or the host gh is
+// authenticated with when empty
+func DefaultHost(host string) string {
+	if host == "" {
+		host, _ = auth.DefaultHost()
+	}
+	return auth.NormalizeHostname(host)
+}
+
+// Repository is a repository on a GitHub host
+type Repository struct {
+	// Host is the GitHub host, empty for the default host of the client
+	Host  string
+	Owner string
+	Name  string
+}
+
+// ParseRepository parses a repository in the owner/repo or HOST/owner/repo
+// format, or a repository URL such as https://github.com/owner/repo or
+// git@github.com:owner/repo.git
+func ParseRepository(repository string) (Repository, error) {
+	if strings.Contains(repository, ":") {
+		u, err := parseURL(repository)
+		if err != nil {
+			return Repository{}, fmt.Errorf("invalid repository URL %q: %v", repository, err)
+		}
+		return repositoryFromURL(u)
+	}
+
+	parts := strings.Split(repository, "/")
+	for _, part := range parts {
+		if part == "" {
+			parts = nil
+			break
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return Repository{Owner: parts[0], Name: parts[1]}, nil
+	case 3:
+		return Repository{Host: strings.ToLower(parts[0]), Owner: parts[1], Name: parts[2]}, nil
+	default:
+		return Repository{}, fmt.Errorf("invalid repository format %q. Expected format: owner/repo, HOST/owner/repo or a repository URL", repository)
+	}
+}
+
+// FullName returns the owner/repo name of the repository
+func (r Repository) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// String returns the repository in the format parsed by ParseRepository
+func (r Repository) String() string {
+	if r.Host == "" {
+		return r.FullName()
+	}
+	return r.Host + "/" + r.FullName()
+}
+
+// Qualify returns the repository prefixed with its host, or the default
+// host when it has none, unless the host is github.com, so that the
+// repositories of different hosts are kept apart
+func Qualify(repository, defaultHost string) string {
+	repo, err := ParseRepository(repository)
+	if err != nil {
+		return repository
+	}
+	host := repo.Host
+	if host == "" {
+		host = defaultHost
+	}
+	if repo.Host = DefaultHost(host); repo.Host == "github.com" {
+		repo.Host = ""
+	}
+	return repo.String()
+}
+
+// parseURL parses a repository URL, including the scp-like syntax of ssh
+// remotes such as git@github.com:owner/repo.git
+func parseURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "ssh://" + strings.Replace(rawURL, ":", "/", 1)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = strings.TrimPrefix(u.Scheme, "git+")
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// repositoryFromURL returns the repository of a URL with the owner/repo path
+func repositoryFromURL(u *url.URL) (Repository, error) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if host == "" || len(parts) != 2 || parts[0] == "" || strings.TrimSuffix(parts[1], ".git") == "" {
+		return Repository{}, fmt.Errorf("invalid repository URL %q. Expected a URL such as https://github.com/owner/repo", u.String())
+	}
+	return Repository{Host: host, Owner: parts[0], Name: strings.TrimSuffix(parts[1], ".git")}, nil
+}
+
+// ResolveRepository returns the given repository, or the one of the named
+// git remote of the current directory when empty
+func ResolveRepository(repository, remote string) (string, error) {
+	if repository != "" {
+		return repository, nil
+	}
+	current, err := CurrentRepository(remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current repository: %w", err)
+	}
+	return current.String(), nil
+}
@@ -0,0 +1,63 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Repository
+	}{
+		{"owner/repo", Repository{Owner: "owner", Name: "repo"}},
+		{"GHE.example.com/owner/repo", Repository{Host: "ghe.example.com", Owner: "owner", Name: "repo"}},
+		{"https://github.com/owner/repo", Repository{Host: "github.com", Owner: "owner", Name: "repo"}},
+		{"https://www.github.com/owner/repo.git/", Repository{Host: "github.com", Owner: "owner", Name: "repo"}},
+		{"git@github.com:owner/repo.git", Repository{Host: "github.com", Owner: "owner", Name: "repo"}},
+		{"ssh://git@ghe.example.com:2222/owner/repo.git", Repository{Host: "ghe.example.com", Owner: "owner", Name: "repo"}},
+		{"git+https://ghe.example.com/owner/repo", Repository{Host: "ghe.example.com", Owner: "owner", Name: "repo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			repo, err := ParseRepository(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, repo)
+		})
+	}
+
+	for _, input := range []string{"repo", "owner/", "a/b/c/d", "https://github.com/owner", "https://github.com/owner/repo/pulls", "ftp://github.com/owner/repo"} {
+		_, err := ParseRepository(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSelectRemote(t *testing.T) {
+	remotes := parseRemotes(`fork	git@github.com:me/repo.git (fetch)
+fork	git@github.com:me/repo.git (push)
+origin	https://github.com/owner/repo.git (fetch)
+origin	https://github.com/owner/repo.git (push)
+`)
+	assert.Equal(t, []Remote{{"fork", "git@github.com:me/repo.git"}, {"origin", "https://github.com/owner/repo.git"}}, remotes)
+
+	// origin is preferred over other remotes
+	repo, err := selectRemote(remotes, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/owner/repo", repo.String())
+
+	repo, err = selectRemote(remotes, "fork", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/me/repo", repo.String())
+
+	_, err = selectRemote(remotes, "upstream", nil)
+	assert.ErrorContains(t, err, `git remote "upstream" not found. Remotes: fork, origin`)
+
+	// Remotes which are not repositories are skipped
+	repo, err = selectRemote([]Remote{{"origin", "/srv/git/repo"}, {"mirror", "https://ghe.example.com/owner/repo"}}, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ghe.example.com/owner/repo", repo.String())
+
+	_, err = selectRemote(nil, "", nil)
+	assert.ErrorContains(t, err, "no git remotes")
+}
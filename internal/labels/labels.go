@@ -0,0 +1,155 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Options configures how label names are mapped before aggregation
+type Options struct {
+	// IgnoreCase merges labels that only differ in case into the lower case name
+	IgnoreCase bool
+	// Aliases maps label names to their canonical name, case-insensitively
+	Aliases map[string]string
+	// Prefix keeps only the labels starting with the prefix, e.g. "area/"
+	Prefix string
+	// Include keeps only the labels matching any of the regular expressions
+	Include []string
+	// Exclude drops the labels matching any of the regular expressions
+	Exclude []string
+}
+
+// Normalizer maps the label names of prs according to Options
+type Normalizer struct {
+	ignoreCase bool
+	aliases    map[string]string
+	prefix     string
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+}
+
+// ParseAliases parses alias definitions of the form
+// "canonical=alias1,alias2" into a map from alias to canonical name
+func ParseAliases(specs []string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, spec := range specs {
+		canonical, names, ok := strings.Cut(spec, "=")
+		canonical = strings.TrimSpace(canonical)
+		if !ok || canonical == "" || strings.TrimSpace(names) == "" {
+			return nil, fmt.Errorf("invalid label alias %q. Expected format: canonical=alias1,alias2", spec)
+		}
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				aliases[name] = canonical
+			}
+		}
+	}
+	return aliases, nil
+}
+
+// New compiles the options into a normalizer
+func New(opts Options) (*Normalizer, error) {
+	n := &Normalizer{
+		ignoreCase: opts.IgnoreCase,
+		aliases:    make(map[string]string, len(opts.Aliases)),
+		prefix:     opts.Prefix,
+	}
+	for alias, canonical := range opts.Aliases {
+		n.aliases[strings.ToLower(alias)] = canonical
+	}
+
+	var err error
+	if n.include, err = compile(opts.Include); err != nil {
+		return nil, err
+	}
+	if n.exclude, err = compile(opts.Exclude); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label pattern %q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// IsZero reports whether the normalizer leaves labels unchanged
+func (n *Normalizer) IsZero() bool {
+	return n == nil || (!n.ignoreCase && len(n.aliases) == 0 && n.prefix == "" && len(n.include) == 0 && len(n.exclude) == 0)
+}
+
+// Name returns the normalized name of the label and whether it is kept
+func (n *Normalizer) Name(label string) (string, bool) {
+	if n == nil {
+		return label, true
+	}
+
+	name := label
+	if canonical, ok := n.aliases[strings.ToLower(name)]; ok {
+		name = canonical
+	}
+	if n.ignoreCase {
+		name = strings.ToLower(name)
+	}
+
+	if n.prefix != "" {
+		hasPrefix := strings.HasPrefix(name, n.prefix)
+		if n.ignoreCase {
+			hasPrefix = strings.HasPrefix(name, strings.ToLower(n.prefix))
+		}
+		if !hasPrefix {
+			return "", false
+		}
+	}
+	if len(n.include) > 0 && !matchAny(n.include, name) {
+		return "", false
+	}
+	if matchAny(n.exclude, name) {
+		return "", false
+	}
+	return name, true
+}
+
+func matchAny(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns copies of the prs with normalized labels. Labels mapped to
+// the same name are merged and prs left without labels count as unlabeled.
+func (n *Normalizer) Apply(prs []types.PullRequest) []types.PullRequest {
+	if n.IsZero() {
+		return prs
+	}
+
+	normalized := make([]types.PullRequest, len(prs))
+	for i, pr := range prs {
+		seen := make(map[string]bool, len(pr.Labels))
+		labels := make([]types.Label, 0, len(pr.Labels))
+		for _, label := range pr.Labels {
+			name, ok := n.Name(label.Name)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			labels = append(labels, types.Label{Name: name})
+		}
+		pr.Labels = labels
+		normalized[i] = pr
+	}
+	return normalized
+}
@@ -0,0 +1,113 @@
+package labels
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAliases(t *testing.T) {
+	tests := []struct {
+		specs    []string
+		expected map[string]string
+		err      string
+	}{
+		{specs: nil, expected: map[string]string{}},
+		{
+			specs:    []string{"bug=defect, Bug-Report", " docs = documentation,,doc"},
+			expected: map[string]string{"defect": "bug", "Bug-Report": "bug", "documentation": "docs", "doc": "docs"},
+		},
+		{specs: []string{"bug"}, err: `invalid label alias "bug"`},
+		{specs: []string{"=defect"}, err: `invalid label alias "=defect"`},
+		{specs: []string{"bug= "}, err: `invalid label alias "bug= "`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.specs), func(t *testing.T) {
+			aliases, err := ParseAliases(tt.specs)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, aliases)
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		err  string
+	}{
+		{name: "include", opts: Options{Include: []string{"^area/", "[a-"}}, err: `invalid label pattern "[a-"`},
+		{name: "exclude", opts: Options{Exclude: []string{"(wip"}}, err: `invalid label pattern "(wip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		label    string
+		expected string
+		kept     bool
+	}{
+		{name: "unchanged", label: "Bug", expected: "Bug", kept: true},
+		{name: "alias is case-insensitive", opts: Options{Aliases: map[string]string{"Defect": "bug"}}, label: "DEFECT", expected: "bug", kept: true},
+		{name: "alias keeps the canonical case", opts: Options{Aliases: map[string]string{"defect": "Bug"}}, label: "defect", expected: "Bug", kept: true},
+		{name: "ignore case", opts: Options{IgnoreCase: true}, label: "Bug", expected: "bug", kept: true},
+		{name: "ignore case lowers the canonical name", opts: Options{IgnoreCase: true, Aliases: map[string]string{"defect": "Bug"}}, label: "Defect", expected: "bug", kept: true},
+		{name: "prefix", opts: Options{Prefix: "area/"}, label: "area/api", expected: "area/api", kept: true},
+		{name: "prefix mismatch", opts: Options{Prefix: "area/"}, label: "Area/api"},
+		{name: "prefix ignoring case", opts: Options{Prefix: "Area/", IgnoreCase: true}, label: "AREA/api", expected: "area/api", kept: true},
+		{name: "include", opts: Options{Include: []string{"^bug$", "^docs"}}, label: "docs/api", expected: "docs/api", kept: true},
+		{name: "include mismatch", opts: Options{Include: []string{"^bug$"}}, label: "bugfix"},
+		{name: "exclude", opts: Options{Exclude: []string{"^wip"}}, label: "wip/api"},
+		{name: "exclude matches the canonical name", opts: Options{Exclude: []string{"^bug$"}, Aliases: map[string]string{"defect": "bug"}}, label: "defect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := New(tt.opts)
+			require.NoError(t, err)
+			name, kept := n.Name(tt.label)
+			assert.Equal(t, tt.expected, name)
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	prs := []types.PullRequest{
+		{Number: 1, Labels: []types.Label{{Name: "Bug"}, {Name: "bug"}, {Name: "defect"}, {Name: "docs"}}},
+		{Number: 2, Labels: []types.Label{{Name: "wip"}}},
+		{Number: 3},
+	}
+
+	n, err := New(Options{IgnoreCase: true, Aliases: map[string]string{"defect": "bug"}, Exclude: []string{"^wip$"}})
+	require.NoError(t, err)
+	normalized := n.Apply(prs)
+
+	require.Len(t, normalized, 3)
+	assert.Equal(t, []types.Label{{Name: "bug"}, {Name: "docs"}}, normalized[0].Labels)
+	assert.Empty(t, normalized[1].Labels)
+	assert.Empty(t, normalized[2].Labels)
+	// The prs passed in are left unchanged
+	assert.Len(t, prs[0].Labels, 4)
+
+	var zero *Normalizer
+	assert.True(t, zero.IsZero())
+	assert.Equal(t, prs, zero.Apply(prs))
+}
@@ -0,0 +1,91 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Table renders the statistics as a table for the terminal
+var Table = Format{
+	Name:         "table",
+	Description:  "Table for the terminal",
+	Extensions:   []string{".txt"},
+	Capabilities: Columns,
+	Renderer: RendererFunc(func(w io.Writer, report types.Report, opts Options) error {
+		utils.PrintStatistics(w, report.Statistics, opts.Columns)
+		return nil
+	}),
+}
+
+// JSON renders the report as indented JSON, the format read by report and compare
+var JSON = Format{
+	Name:         "json",
+	Description:  "Versioned JSON report, readable by report and compare",
+	Extensions:   []string{".json"},
+	Capabilities: Structured | Input,
+	Renderer: RendererFunc(func(w io.Writer, report types.Report, opts Options) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if opts.Legacy {
+			return encoder.Encode(report.Statistics.Legacy())
+		}
+		return encoder.Encode(report)
+	}),
+}
+
+// CSV renders the statistics as comma separated values
+var CSV = Format{
+	Name:         "csv",
+	Description:  "Comma separated values",
+	Extensions:   []string{".csv"},
+	Capabilities: Columns | Structured,
+	Renderer: RendererFunc(func(w io.Writer, report types.Report, opts Options) error {
+		return utils.WriteDelimitedOutput(w, report.Statistics, ',', opts.Columns)
+	}),
+}
+
+// TSV renders the statistics as tab separated values
+var TSV = Format{
+	Name:         "tsv",
+	Description:  "Tab separated values",
+	Extensions:   []string{".tsv"},
+	Capabilities: Columns | Structured,
+	Renderer: RendererFunc(func(w io.Writer, report types.Report, opts Options) error {
+		return utils.WriteDelimitedOutput(w, report.Statistics, '\t', opts.Columns)
+	}),
+}
+
+// Markdown renders the statistics as a GitHub flavored markdown table
+var Markdown = Format{
+	Name:         "markdown",
+	Aliases:      []string{"md"},
+	Description:  "GitHub flavored markdown table",
+	Extensions:   []string{".md", ".markdown"},
+	Capabilities: Columns,
+	Renderer: RendererFunc(func(w io.Writer, report types.Report, opts Options) error {
+		utils.WriteMarkdownOutput(w, report.Statistics, opts.Columns)
+		return nil
+	}),
+}
+
+// HTML renders the statistics as a standalone HTML document
+var HTML = Format{
+	Name:         "html",
+	Description:  "Standalone HTML document",
+	Extensions:   []string{".html", ".htm"},
+	Capabilities: Columns,
+	Renderer:     RendererFunc(writeHTML),
+}
+
+// Prometheus renders the statistics in the Prometheus text exposition format
+var Prometheus = Format{
+	Name:         "prom",
+	Aliases:      []string{"prometheus"},
+	Description:  "Prometheus text exposition format, e.g. for the node exporter textfile collector",
+	Extensions:   []string{".prom"},
+	Capabilities: Structured,
+	Renderer:     RendererFunc(writePrometheus),
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+var htmlTemplate = template.Must(template.New("stats").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Pull request statistics</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 6px 13px; }
+th { background: #f6f8fa; }
+td.number { text-align: right; font-variant-numeric: tabular-nums; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Pull request statistics{{with .Repositories}} of {{range $i, $r := .}}{{if $i}}, {{end}}{{$r}}{{end}}{{end}}</h1>
+<p>Generated at {{.GeneratedAt}}</p>
+<table>
+<thead>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td{{if .Number}} class="number"{{end}}>{{.Value}}</td>{{end}}</tr>
+{{end}}</tbody>
+<tfoot>
+<tr>{{range .Total}}<td{{if .Number}} class="number"{{end}}>{{.Value}}</td>{{end}}</tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+type htmlCell struct {
+	Value  string
+	Number bool
+}
+
+// writeHTML writes the statistics as a standalone HTML document
+func writeHTML(w io.Writer, report types.Report, opts Options) error {
+	cells := func(r utils.Row) []htmlCell {
+		row := make([]htmlCell, len(opts.Columns))
+		for i, column := range opts.Columns {
+			row[i] = htmlCell{Value: column.Value(r), Number: column.Name != "label"}
+		}
+		return row
+	}
+
+	data := struct {
+		Repositories []string
+		GeneratedAt  string
+		Headers      []string
+		Rows         [][]htmlCell
+		Total        []htmlCell
+	}{
+		Repositories: report.Repositories,
+		GeneratedAt:  report.GeneratedAt.Format(time.RFC3339),
+		Total:        cells(utils.TotalRow(report.Statistics.OverallStats)),
+	}
+	for _, column := range opts.Columns {
+		data.Headers = append(data.Headers, column.Header)
+	}
+	for _, stat := range report.Statistics.LabelStats {
+		data.Rows = append(data.Rows, cells(utils.LabelRow(stat)))
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
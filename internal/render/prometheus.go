@@ -0,0 +1,115 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// MetricPrefix prefixes the names of the Prometheus metrics
+const MetricPrefix = "gh_pr_stats"
+
+// Metric is a single Prometheus metric family in the text exposition format
+type Metric struct {
+	Name    string
+	Help    string
+	Kind    string
+	samples []string
+}
+
+// Add adds a sample with the label name and value pairs
+func (m *Metric) Add(value float64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	m.samples = append(m.samples, fmt.Sprintf("%s_%s{%s} %v", MetricPrefix, m.Name, strings.Join(pairs, ","), value))
+}
+
+// Write writes the metric family, nothing when it has no samples
+func (m *Metric) Write(w io.Writer) {
+	if len(m.samples) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s_%s %s\n", MetricPrefix, m.Name, m.Help)
+	fmt.Fprintf(w, "# TYPE %s_%s %s\n", MetricPrefix, m.Name, m.Kind)
+	for _, sample := range m.samples {
+		fmt.Fprintln(w, sample)
+	}
+}
+
+// StatisticsMetrics are the metric families of the label and overall statistics
+type StatisticsMetrics struct {
+	prs                   *Metric
+	openPercentage        *Metric
+	avgDays               *Metric
+	medianDays            *Metric
+	overallPrs            *Metric
+	overallOpenPercentage *Metric
+	overallAvgDays        *Metric
+	overallMedianDays     *Metric
+}
+
+// NewStatisticsMetrics creates the metric families without samples
+func NewStatisticsMetrics() *StatisticsMetrics {
+	return &StatisticsMetrics{
+		prs:                   &Metric{Name: "pull_requests", Help: "Number of prs by label and state.", Kind: "gauge"},
+		openPercentage:        &Metric{Name: "open_percentage", Help: "Percentage of open prs by label.", Kind: "gauge"},
+		avgDays:               &Metric{Name: "avg_days_to_close", Help: "Average days to close prs by label.", Kind: "gauge"},
+		medianDays:            &Metric{Name: "median_days_to_close", Help: "Median days to close prs by label.", Kind: "gauge"},
+		overallPrs:            &Metric{Name: "overall_pull_requests", Help: "Number of prs by state.", Kind: "gauge"},
+		overallOpenPercentage: &Metric{Name: "overall_open_percentage", Help: "Percentage of open prs.", Kind: "gauge"},
+		overallAvgDays:        &Metric{Name: "overall_avg_days_to_close", Help: "Average days to close prs.", Kind: "gauge"},
+		overallMedianDays:     &Metric{Name: "overall_median_days_to_close", Help: "Median days to close prs.", Kind: "gauge"},
+	}
+}
+
+// Add adds the samples of the statistics, labeled with the given label name
+// and value pairs, such as the repository
+func (m *StatisticsMetrics) Add(stats types.Statistics, labels ...string) {
+	with := func(pairs ...string) []string {
+		return append(append([]string(nil), labels...), pairs...)
+	}
+
+	for _, stat := range stats.LabelStats {
+		m.prs.Add(float64(stat.Open), with("label", stat.Name, "state", "open")...)
+		m.prs.Add(float64(stat.Closed), with("label", stat.Name, "state", "closed")...)
+		m.openPercentage.Add(stat.OpenPercentage, with("label", stat.Name)...)
+		m.avgDays.Add(stat.AvgDaysToClose, with("label", stat.Name)...)
+		m.medianDays.Add(stat.MedianDaysToClose, with("label", stat.Name)...)
+	}
+
+	overall := stats.OverallStats
+	m.overallPrs.Add(float64(overall.Open), with("state", "open")...)
+	m.overallPrs.Add(float64(overall.Closed), with("state", "closed")...)
+	m.overallOpenPercentage.Add(overall.OpenPercentage, labels...)
+	m.overallAvgDays.Add(overall.AvgDaysToClose, labels...)
+	m.overallMedianDays.Add(overall.MedianDaysToClose, labels...)
+}
+
+// Write writes every metric family
+func (m *StatisticsMetrics) Write(w io.Writer) {
+	for _, metric := range []*Metric{
+		m.prs, m.openPercentage, m.avgDays, m.medianDays,
+		m.overallPrs, m.overallOpenPercentage, m.overallAvgDays, m.overallMedianDays,
+	} {
+		metric.Write(w)
+	}
+}
+
+// writePrometheus writes the statistics in the Prometheus text exposition
+// format, e.g. for the textfile collector of the node exporter. The samples
+// are labeled with the repository of single repository reports.
+func writePrometheus(w io.Writer, report types.Report, opts Options) error {
+	var labels []string
+	if len(report.Repositories) == 1 {
+		labels = []string{"repository", report.Repositories[0]}
+	}
+
+	metrics := NewStatisticsMetrics()
+	metrics.Add(report.Statistics, labels...)
+	metrics.Write(w)
+	return nil
+}
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Options configure how a report is rendered
+type Options struct {
+	// Columns are the columns written by the formats with the Columns capability
+	Columns []utils.Column
+	// Legacy writes JSON in the shape of schema version 1, the bare
+	// statistics without the metadata of the report
+	Legacy bool
+}
+
+// Renderer writes a report in an output format
+type Renderer interface {
+	Render(w io.Writer, report types.Report, opts Options) error
+}
+
+// RendererFunc adapts a function to the Renderer interface
+type RendererFunc func(w io.Writer, report types.Report, opts Options) error
+
+func (f RendererFunc) Render(w io.Writer, report types.Report, opts Options) error {
+	return f(w, report, opts)
+}
+
+// Capability is a feature of an output format
+type Capability uint
+
+const (
+	// Columns formats write the selected columns only
+	Columns Capability = 1 << iota
+	// Structured formats are meant to be parsed by other programs
+	Structured
+	// Input formats can be read back by the report and compare commands
+	Input
+)
+
+var capabilityNames = []struct {
+	capability Capability
+	name       string
+}{
+	{Columns, "columns"},
+	{Structured, "structured"},
+	{Input, "input"},
+}
+
+// String returns the names of the capabilities, comma separated
+func (c Capability) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c&n.capability != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Format is an output format of the statistics
+type Format struct {
+	Name        string
+	Aliases     []string
+	Description string
+	// Extensions are the file extensions of the format, including the dot
+	Extensions   []string
+	Capabilities Capability
+	Renderer     Renderer
+}
+
+// Has reports whether the format has every given capability
+func (f Format) Has(c Capability) bool {
+	return f.Capabilities&c == c
+}
+
+// Registry holds the output formats. The first registered format is the
+// default one.
+type Registry struct {
+	formats []Format
+}
+
+// NewRegistry creates a registry of the formats. It panics when two formats
+// share a name, an alias or an extension.
+func NewRegistry(formats ...Format) *Registry {
+	r := &Registry{}
+	for _, f := range formats {
+		if err := r.Register(f); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Default is the registry of the built-in formats
+var Default = NewRegistry(Table, JSON, CSV, TSV, Markdown, HTML, Prometheus)
+
+// Register adds a format to the registry
+func (r *Registry) Register(f Format) error {
+	if f.Name == "" || f.Renderer == nil {
+		return fmt.Errorf("invalid format %q: a name and a renderer are required", f.Name)
+	}
+	for _, name := range append([]string{f.Name}, f.Aliases...) {
+		if _, ok := r.find(name); ok {
+			return fmt.Errorf("format %q is already registered", name)
+		}
+	}
+	for _, ext := range f.Extensions {
+		if existing, ok := r.ByExtension(ext); ok {
+			return fmt.Errorf("extension %s is already registered by format %q", ext, existing.Name)
+		}
+	}
+
+	r.formats = append(r.formats, f)
+	return nil
+}
+
+// Formats returns the registered formats in order of registration
+func (r *Registry) Formats() []Format {
+	return r.formats
+}
+
+// Names returns the names of the registered formats
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.formats))
+	for i, f := range r.formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Lookup returns the format of the name or alias, case insensitively. An
+// empty name is the default format.
+func (r *Registry) Lookup(name string) (Format, error) {
+	if name == "" && len(r.formats) > 0 {
+		return r.formats[0], nil
+	}
+	if f, ok := r.find(name); ok {
+		return f, nil
+	}
+	return Format{}, fmt.Errorf("unsupported format %q. Supported formats: %s", name, strings.Join(r.Names(), ", "))
+}
+
+func (r *Registry) find(name string) (Format, bool) {
+	for _, f := range r.formats {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+		for _, alias := range f.Aliases {
+			if strings.EqualFold(alias, name) {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// ByExtension returns the format of a file extension such as .csv
+func (r *Registry) ByExtension(ext string) (Format, bool) {
+	for _, f := range r.formats {
+		for _, e := range f.Extensions {
+			if strings.EqualFold(e, ext) {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// ForFile returns the format of the extension of filename
+func (r *Registry) ForFile(filename string) (Format, bool) {
+	return r.ByExtension(filepath.Ext(filename))
+}
+
+// Render writes the report to w in the named format
+func (r *Registry) Render(w io.Writer, report types.Report, name string, opts Options) error {
+	f, err := r.Lookup(name)
+	if err != nil {
+		return err
+	}
+	return f.Renderer.Render(w, report, opts)
+}
+
+// PrintFormats lists the formats of the registry
+func PrintFormats(w io.Writer, r *Registry) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Format", "Aliases", "Extensions", "Capabilities", "Description"})
+	for _, f := range r.Formats() {
+		t.AppendRow(table.Row{f.Name, strings.Join(f.Aliases, ", "), strings.Join(f.Extensions, ", "), f.Capabilities.String(), f.Description})
+	}
+	t.Render()
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	f, err := Default.Lookup("")
+	assert.NoError(t, err)
+	assert.Equal(t, "table", f.Name)
+
+	f, err = Default.Lookup("MD")
+	assert.NoError(t, err)
+	assert.Equal(t, "markdown", f.Name)
+	assert.True(t, f.Has(Columns))
+	assert.False(t, f.Has(Columns|Structured))
+
+	_, err = Default.Lookup("xml")
+	assert.ErrorContains(t, err, "Supported formats: table, json, csv, tsv, markdown")
+
+	f, ok := Default.ForFile("out/stats.CSV")
+	assert.True(t, ok)
+	assert.Equal(t, "csv", f.Name)
+	_, ok = Default.ForFile("stats")
+	assert.False(t, ok)
+
+	assert.Equal(t, "columns, structured", CSV.Capabilities.String())
+}
+
+func TestRegister(t *testing.T) {
+	plain := RendererFunc(func(w io.Writer, report types.Report, opts Options) error {
+		_, err := io.WriteString(w, report.Statistics.LabelStats[0].Name)
+		return err
+	})
+
+	r := NewRegistry(JSON)
+	assert.NoError(t, r.Register(Format{Name: "plain", Aliases: []string{"txt"}, Extensions: []string{".txt"}, Renderer: plain}))
+	assert.ErrorContains(t, r.Register(Format{Name: "JSON", Renderer: plain}), "already registered")
+	assert.ErrorContains(t, r.Register(Format{Name: "text", Aliases: []string{"plain"}, Renderer: plain}), "already registered")
+	assert.ErrorContains(t, r.Register(Format{Name: "text", Extensions: []string{".json"}, Renderer: plain}), "already registered by format \"json\"")
+	assert.Error(t, r.Register(Format{Name: "text"}))
+	assert.Panics(t, func() { NewRegistry(JSON, JSON) })
+
+	var buf bytes.Buffer
+	report := types.NewReport(types.Statistics{LabelStats: []types.LabelStat{{Name: "bug"}}})
+	assert.NoError(t, r.Render(&buf, report, "txt", Options{}))
+	assert.Equal(t, "bug", buf.String())
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"golang.org/x/exp/slog"
+)
+
+// SyncFunc fetches the prs of a repository updated since the given time.
+// A nil since means a full fetch.
+type SyncFunc func(repository string, since *time.Time) ([]types.PullRequest, error)
+
+// repoState holds the cached data of a single repository
+type repoState struct {
+	prs            map[int]types.PullRequest
+	stats          types.Statistics
+	lastSync       *time.Time
+	lastScrape     time.Time
+	scrapeDuration time.Duration
+	scrapeErrors   int
+	lastError      error
+}
+
+// Server periodically syncs the prs of the configured repositories and
+// exposes the calculated statistics over HTTP
+type Server struct {
+	repositories []string
+	interval     time.Duration
+	sync         SyncFunc
+
+	// Filter selects the synced prs the statistics are calculated from
+	Filter func([]types.PullRequest) []types.PullRequest
+	// Days measures the durations, calendar days when nil
+	Days stats.DaysFunc
+	// Logger receives the sync errors, none when nil
+	Logger *slog.Logger
+	// Report wraps the statistics of /stats in a report, types.NewReport
+	// when nil
+	Report func(repositories []string, stats types.Statistics) types.Report
+
+	mu    sync.RWMutex
+	repos map[string]*repoState
+}
+
+// New creates a server for the given repositories
+func New(repositories []string, interval time.Duration, sync SyncFunc) *Server {
+	repos := make(map[string]*repoState, len(repositories))
+	for _, repository := range repositories {
+		repos[repository] = &repoState{prs: make(map[int]types.PullRequest)}
+	}
+
+	return &Server{
+		repositories: repositories,
+		interval:     interval,
+		sync:         sync,
+		repos:        repos,
+	}
+}
+
+// Refresh syncs every repository once. Errors are recorded per repository
+// and reported via metrics instead of being returned.
+func (s *Server) Refresh() {
+	for _, repository := range s.repositories {
+		s.refreshRepository(repository)
+	}
+}
+
+func (s *Server) refreshRepository(repository string) {
+	s.mu.RLock()
+	since := s.repos[repository].lastSync
+	s.mu.RUnlock()
+
+	started := time.Now()
+	prs, err := s.sync(repository, since)
+	duration := time.Since(started)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.repos[repository]
+	state.lastScrape = started
+	state.scrapeDuration = duration
+
+	if err != nil {
+		state.scrapeErrors++
+		state.lastError = err
+		if s.Logger != nil {
+			s.Logger.Debug(fmt.Sprintf("failed to sync %s: %v", repository, err))
+		}
+		return
+	}
+
+	for _, pr := range prs {
+		state.prs[pr.Number] = pr
+	}
+
+	all := make([]types.PullRequest, 0, len(state.prs))
+	for _, pr := range state.prs {
+		all = append(all, pr)
+	}
+	if s.Filter != nil {
+		all = s.Filter(all)
+	}
+
+	state.stats = stats.CalculateStatistics(all, s.Days)
+	state.lastSync = &started
+	state.lastError = nil
+}
+
+// Run refreshes the data on the configured interval until ctx is cancelled
+func (s *Server) Run(ctx context.Context) {
+	s.Refresh()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh()
+		}
+	}
+}
+
+// Handler returns the http handler serving /metrics, /stats and /healthz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	return mux
+}
+
+// ListenAndServe serves the handler on addr and shuts down gracefully when
+// ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go s.Run(ctx)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to serve on %s: %v", addr, err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %v", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// report wraps the statistics of the repository in a report
+func (s *Server) report(repository string, stats types.Statistics) types.Report {
+	if s.Report != nil {
+		return s.Report([]string{repository}, stats)
+	}
+	report := types.NewReport(stats)
+	report.Repositories = []string{repository}
+	return report
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	result := make(map[string]types.Report, len(s.repos))
+	for repository, state := range s.repos {
+		if state.lastSync != nil {
+			result[repository] = s.report(repository, state.stats)
+		}
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	writeMetrics(w, s.repositories, s.repos)
+}
+
+func writeMetrics(w io.Writer, repositories []string, repos map[string]*repoState) {
+	up := &render.Metric{Name: "scrape_success", Help: "Whether the last sync of the repository succeeded.", Kind: "gauge"}
+	scrapeErrors := &render.Metric{Name: "scrape_errors_total", Help: "Number of failed syncs of the repository.", Kind: "counter"}
+	scrapeDuration := &render.Metric{Name: "scrape_duration_seconds", Help: "Duration of the last sync of the repository.", Kind: "gauge"}
+	lastSync := &render.Metric{Name: "last_sync_timestamp_seconds", Help: "Unix time of the last successful sync of the repository.", Kind: "gauge"}
+	statistics := render.NewStatisticsMetrics()
+
+	sorted := append([]string(nil), repositories...)
+	sort.Strings(sorted)
+
+	for _, repository := range sorted {
+		state := repos[repository]
+
+		success := 1.0
+		if state.lastError != nil || state.lastScrape.IsZero() {
+			success = 0
+		}
+		up.Add(success, "repository", repository)
+		scrapeErrors.Add(float64(state.scrapeErrors), "repository", repository)
+		if !state.lastScrape.IsZero() {
+			scrapeDuration.Add(state.scrapeDuration.Seconds(), "repository", repository)
+		}
+
+		if state.lastSync == nil {
+			continue
+		}
+		lastSync.Add(float64(state.lastSync.Unix()), "repository", repository)
+
+		statistics.Add(state.stats, "repository", repository)
+	}
+
+	for _, m := range []*render.Metric{up, scrapeErrors, scrapeDuration, lastSync} {
+		m.Write(w)
+	}
+	statistics.Write(w)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerRefresh(t *testing.T) {
+	createdAt := time.Now().Add(-48 * time.Hour)
+	closedAt := time.Now()
+
+	var calls []*time.Time
+	sync := func(repository string, since *time.Time) ([]types.PullRequest, error) {
+		if repository == "owner/broken" {
+			return nil, errors.New("boom")
+		}
+		calls = append(calls, since)
+		if since == nil {
+			return []types.PullRequest{
+				{Number: 1, State: "open", CreatedAt: &createdAt, Labels: []types.Label{{Name: "bug"}}},
+				{Number: 2, State: "open", CreatedAt: &createdAt},
+			}, nil
+		}
+		// pr #1 was closed since the last sync
+		return []types.PullRequest{
+			{Number: 1, State: "closed", CreatedAt: &createdAt, ClosedAt: &closedAt, Labels: []types.Label{{Name: "bug"}}},
+		}, nil
+	}
+
+	srv := New([]string{"owner/repo", "owner/broken"}, time.Minute, sync)
+	srv.Refresh()
+	srv.Refresh()
+
+	assert.Len(t, calls, 2)
+	assert.Nil(t, calls[0], "first sync should be a full fetch")
+	assert.NotNil(t, calls[1], "second sync should be incremental")
+
+	handler := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var result map[string]types.Report
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.NotContains(t, result, "owner/broken")
+	assert.Equal(t, types.SchemaVersion, result["owner/repo"].SchemaVersion)
+	assert.Equal(t, []string{"owner/repo"}, result["owner/repo"].Repositories)
+	assert.Equal(t, 2, result["owner/repo"].Statistics.OverallStats.Total)
+	assert.Equal(t, 1, result["owner/repo"].Statistics.OverallStats.Closed)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	assert.Contains(t, body, `gh_pr_stats_scrape_success{repository="owner/broken"} 0`)
+	assert.Contains(t, body, `gh_pr_stats_scrape_errors_total{repository="owner/broken"} 2`)
+	assert.Contains(t, body, `gh_pr_stats_scrape_success{repository="owner/repo"} 1`)
+	assert.Contains(t, body, `gh_pr_stats_pull_requests{repository="owner/repo",label="bug",state="closed"} 1`)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,131 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// ageBucket is an age range in days, upper bound exclusive
+type ageBucket struct {
+	name    string
+	maxDays float64
+}
+
+// ageBuckets are the ranges open prs are distributed into
+var ageBuckets = []ageBucket{
+	{name: "<1d", maxDays: 1},
+	{name: "1-7d", maxDays: 7},
+	{name: "7-30d", maxDays: 30},
+	{name: "30-90d", maxDays: 90},
+	{name: ">90d"},
+}
+
+func ageBucketIndex(ageDays float64) int {
+	for i, bucket := range ageBuckets {
+		if bucket.maxDays > 0 && ageDays < bucket.maxDays {
+			return i
+		}
+	}
+	return len(ageBuckets) - 1
+}
+
+func newLabelAging(name string) *types.LabelAging {
+	buckets := make([]types.AgeBucket, len(ageBuckets))
+	for i, bucket := range ageBuckets {
+		buckets[i].Name = bucket.name
+	}
+	return &types.LabelAging{Name: name, Buckets: buckets}
+}
+
+// CalculateAging distributes the open prs by age per label and lists the
+// oldest ones. A pr is stale when it had no activity for staleAfter,
+// measured in the days of the ages; zero disables the stale detection.
+func CalculateAging(prs []types.PullRequest, now time.Time, staleAfter time.Duration, oldest int, days DaysFunc) types.AgingReport {
+	overall := newLabelAging("Total")
+	labelAging := make(map[string]*types.LabelAging)
+	var open []types.OpenPullRequest
+	staleAfterDays := staleAfter.Hours() / 24
+
+	for _, pr := range prs {
+		if pr.State != "open" || pr.CreatedAt == nil {
+			continue
+		}
+
+		ageDays := days.Between(*pr.CreatedAt, now)
+		bucket := ageBucketIndex(ageDays)
+
+		lastActivity := pr.UpdatedAt
+		if lastActivity == nil {
+			lastActivity = pr.CreatedAt
+		}
+		stale := staleAfter > 0 && days.Between(*lastActivity, now) >= staleAfterDays
+
+		labels := make([]string, 0, len(pr.Labels))
+		for _, label := range pr.Labels {
+			labels = append(labels, label.Name)
+		}
+		names := labels
+		if len(names) == 0 {
+			names = []string{types.UnlabeledLabel}
+		}
+
+		for _, aging := range append([]*types.LabelAging{overall}, lookupLabelAging(labelAging, names)...) {
+			aging.Open++
+			aging.Buckets[bucket].Count++
+			if stale {
+				aging.Stale++
+			}
+		}
+
+		open = append(open, types.OpenPullRequest{
+			Number:       pr.Number,
+			Title:        pr.Title,
+			Author:       pr.User.Login,
+			Labels:       labels,
+			CreatedAt:    pr.CreatedAt,
+			LastActivity: lastActivity,
+			AgeDays:      ageDays,
+			Stale:        stale,
+		})
+	}
+
+	labelAgingSlice := make([]types.LabelAging, 0, len(labelAging))
+	for _, aging := range labelAging {
+		labelAgingSlice = append(labelAgingSlice, *aging)
+	}
+	sort.Slice(labelAgingSlice, func(i, j int) bool {
+		if labelAgingSlice[i].Open != labelAgingSlice[j].Open {
+			return labelAgingSlice[i].Open > labelAgingSlice[j].Open
+		}
+		return labelAgingSlice[i].Name < labelAgingSlice[j].Name
+	})
+
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].AgeDays > open[j].AgeDays
+	})
+	if oldest >= 0 && len(open) > oldest {
+		open = open[:oldest]
+	}
+
+	return types.AgingReport{
+		StaleAfterDays: staleAfterDays,
+		LabelAging:     labelAgingSlice,
+		OverallAging:   *overall,
+		Oldest:         open,
+	}
+}
+
+func lookupLabelAging(labelAging map[string]*types.LabelAging, names []string) []*types.LabelAging {
+	result := make([]*types.LabelAging, 0, len(names))
+	for _, name := range names {
+		aging, exists := labelAging[name]
+		if !exists {
+			aging = newLabelAging(name)
+			labelAging[name] = aging
+		}
+		result = append(result, aging)
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// OtherLabel is the name of the row aggregating the labels cut off by Top
+const OtherLabel = "*other*"
+
+// ArrangeOptions controls which label rows are kept and in which order
+type ArrangeOptions struct {
+	// Less orders the label rows. The order of CalculateStatistics is kept when nil.
+	Less func(a, b types.LabelStat) bool
+	// Top keeps only the first N rows and aggregates the rest into OtherLabel
+	Top int
+	// MinCount drops the rows with fewer prs
+	MinCount int
+	// Days measures the durations of the aggregated row
+	Days DaysFunc
+}
+
+// Arrange filters, sorts and limits the label rows of statistics calculated
+// from prs. The overall statistics are left untouched.
+func Arrange(prs []types.PullRequest, statistics types.Statistics, opts ArrangeOptions) types.Statistics {
+	rows := make([]types.LabelStat, 0, len(statistics.LabelStats))
+	for _, stat := range statistics.LabelStats {
+		if stat.Total >= opts.MinCount {
+			rows = append(rows, stat)
+		}
+	}
+
+	if opts.Less != nil {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return opts.Less(rows[i], rows[j])
+		})
+	}
+
+	if opts.Top > 0 && len(rows) > opts.Top {
+		other := make(map[string]bool, len(rows)-opts.Top)
+		for _, stat := range rows[opts.Top:] {
+			other[stat.Name] = true
+		}
+		rows = append(rows[:opts.Top:opts.Top], aggregateLabels(prs, other, opts.Days))
+	}
+
+	statistics.LabelStats = rows
+	return statistics
+}
+
+// aggregateLabels calculates the statistics of the prs having any of the
+// given labels, counting each pr once
+func aggregateLabels(prs []types.PullRequest, labels map[string]bool, days DaysFunc) types.LabelStat {
+	var matched []types.PullRequest
+	for _, pr := range prs {
+		if hasAnyLabel(pr, labels) {
+			pr.Labels = []types.Label{{Name: OtherLabel}}
+			matched = append(matched, pr)
+		}
+	}
+
+	for _, stat := range CalculateStatistics(matched, days).LabelStats {
+		if stat.Name == OtherLabel {
+			return stat
+		}
+	}
+	return types.LabelStat{Name: OtherLabel}
+}
+
+func hasAnyLabel(pr types.PullRequest, labels map[string]bool) bool {
+	if len(pr.Labels) == 0 {
+		return labels[types.UnlabeledLabel]
+	}
+	for _, label := range pr.Labels {
+		if labels[label.Name] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/calendar"
+)
+
+// DaysFunc measures the time between from and to in days. A nil DaysFunc
+// measures calendar days.
+type DaysFunc func(from, to time.Time) float64
+
+// WallClockDays measures durations in calendar days
+func WallClockDays(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24
+}
+
+// CalendarDays returns the DaysFunc measuring the working time of the
+// calendar. A nil calendar measures calendar days.
+func CalendarDays(c *calendar.Calendar) DaysFunc {
+	if c == nil {
+		return WallClockDays
+	}
+	return c.WorkingDays
+}
+
+// Between returns the time between from and to in days
+func (d DaysFunc) Between(from, to time.Time) float64 {
+	if d == nil {
+		return WallClockDays(from, to)
+	}
+	return d(from, to)
+}
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// GroupByOptions lists the supported values of Regroup
+var GroupByOptions = []string{"label", "author", "author-type", "primary-label"}
+
+// PrimaryLabel returns the single label a pr is attributed to: the first
+// label of the priority list the pr has, otherwise its alphabetically first
+// label. Unlabeled prs are attributed to UnlabeledLabel.
+func PrimaryLabel(pr types.PullRequest, priority []string) string {
+	if len(pr.Labels) == 0 {
+		return types.UnlabeledLabel
+	}
+
+	for _, candidate := range priority {
+		for _, label := range pr.Labels {
+			if strings.EqualFold(label.Name, candidate) {
+				return label.Name
+			}
+		}
+	}
+
+	names := make([]string, len(pr.Labels))
+	for i, label := range pr.Labels {
+		names[i] = label.Name
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// Regroup returns copies of the prs whose labels are replaced by the group
+// they belong to, so CalculateStatistics aggregates rows per group. The
+// priority list is only used by the primary-label group.
+func Regroup(prs []types.PullRequest, groupBy string, priority []string) ([]types.PullRequest, error) {
+	var key func(types.PullRequest) string
+	switch groupBy {
+	case "", "label":
+		return prs, nil
+	case "author":
+		key = func(pr types.PullRequest) string { return pr.User.Login }
+	case "author-type":
+		key = func(pr types.PullRequest) string {
+			if pr.User.IsBot() {
+				return "bot"
+			}
+			return "human"
+		}
+	case "primary-label":
+		key = func(pr types.PullRequest) string { return PrimaryLabel(pr, priority) }
+	default:
+		return nil, fmt.Errorf("invalid group %q. Supported: %s", groupBy, strings.Join(GroupByOptions, ", "))
+	}
+
+	grouped := make([]types.PullRequest, len(prs))
+	for i, pr := range prs {
+		pr.Labels = []types.Label{{Name: key(pr)}}
+		grouped[i] = pr
+	}
+	return grouped, nil
+}
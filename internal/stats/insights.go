@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// attribute extracts a numeric pr attribute. ok is false when it is unknown.
+type attribute struct {
+	name  string
+	value func(pr types.PullRequest, reviews []types.Review) (value float64, ok bool)
+}
+
+// insightAttributes returns the ranked attributes, the created hour and
+// weekday in the given location
+func insightAttributes(location *time.Location) []attribute {
+	return []attribute{
+		{"changed lines", func(pr types.PullRequest, _ []types.Review) (float64, bool) {
+			if pr.Size == nil {
+				return 0, false
+			}
+			return float64(pr.Size.Lines()), true
+		}},
+		{"changed files", func(pr types.PullRequest, _ []types.Review) (float64, bool) {
+			if pr.Size == nil {
+				return 0, false
+			}
+			return float64(pr.Size.ChangedFiles), true
+		}},
+		{"commits", func(pr types.PullRequest, _ []types.Review) (float64, bool) {
+			if pr.Size == nil {
+				return 0, false
+			}
+			return float64(pr.Size.Commits), true
+		}},
+		{"reviewers", func(pr types.PullRequest, reviews []types.Review) (float64, bool) {
+			if reviews == nil {
+				return 0, false
+			}
+			reviewers := make(map[string]bool)
+			for _, review := range reviews {
+				reviewers[review.User.Login] = true
+			}
+			return float64(len(reviewers)), true
+		}},
+		{"labels", func(pr types.PullRequest, _ []types.Review) (float64, bool) {
+			return float64(len(pr.Labels)), true
+		}},
+		{"created hour", func(pr types.PullRequest, _ []types.Review) (float64, bool) {
+			return float64(pr.CreatedAt.In(location).Hour()), true
+		}},
+		{"created weekday", func(pr types.PullRequest, _ []types.Review) (float64, bool) {
+			return float64(pr.CreatedAt.In(location).Weekday()), true
+		}},
+	}
+}
+
+// CalculateInsights computes the Spearman rank correlation of time to merge
+// with every pr attribute over the merged prs, strongest first. The created
+// hour and weekday are taken in the location, UTC when nil.
+func CalculateInsights(prs []types.PullRequest, reviews map[int][]types.Review, days DaysFunc, location *time.Location) types.InsightsReport {
+	if location == nil {
+		location = time.UTC
+	}
+	attributes := insightAttributes(location)
+
+	var merged []types.PullRequest
+	var mergeTimes []float64
+	for _, pr := range prs {
+		if mergeDays, ok := daysToMerge(pr, days); ok {
+			merged = append(merged, pr)
+			mergeTimes = append(mergeTimes, mergeDays)
+		}
+	}
+
+	correlations := make([]types.Correlation, 0, len(attributes))
+	for _, attr := range attributes {
+		var xs, ys []float64
+		for i, pr := range merged {
+			var prReviews []types.Review
+			if reviews != nil {
+				prReviews = reviews[pr.Number]
+				if prReviews == nil {
+					prReviews = []types.Review{}
+				}
+			}
+			if x, ok := attr.value(pr, prReviews); ok {
+				xs = append(xs, x)
+				ys = append(ys, mergeTimes[i])
+			}
+		}
+
+		coefficient, ok := spearman(xs, ys)
+		strength := "n/a"
+		if ok {
+			strength = correlationStrength(coefficient)
+		}
+		correlations = append(correlations, types.Correlation{
+			Attribute:   attr.name,
+			Coefficient: coefficient,
+			Strength:    strength,
+			Samples:     len(xs),
+		})
+	}
+
+	sort.SliceStable(correlations, func(i, j int) bool {
+		return math.Abs(correlations[i].Coefficient) > math.Abs(correlations[j].Coefficient)
+	})
+
+	return types.InsightsReport{
+		MergedPullRequests: len(merged),
+		Correlations:       correlations,
+	}
+}
+
+// spearman returns the Spearman rank correlation coefficient of xs and ys.
+// ok is false when it is undefined because a series has no variance.
+func spearman(xs, ys []float64) (float64, bool) {
+	if len(xs) < 2 {
+		return 0, false
+	}
+
+	rx, ry := ranks(xs), ranks(ys)
+	mx, my := mean(rx), mean(ry)
+
+	var cov, vx, vy float64
+	for i := range rx {
+		dx, dy := rx[i]-mx, ry[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(vx*vy), true
+}
+
+// ranks returns the 1-based ranks of values, averaging the ranks of ties
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return values[order[i]] < values[order[j]]
+	})
+
+	result := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			result[order[k]] = rank
+		}
+		i = j + 1
+	}
+	return result
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func correlationStrength(coefficient float64) string {
+	switch r := math.Abs(coefficient); {
+	case r < 0.1:
+		return "negligible"
+	case r < 0.3:
+		return "weak"
+	case r < 0.5:
+		return "moderate"
+	default:
+		return "strong"
+	}
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// CalculateLabelMatrix counts how often each pair of labels is used on the
+// same pr. Only the top most used labels are included when top is positive.
+func CalculateLabelMatrix(prs []types.PullRequest, top int) types.LabelMatrix {
+	counts := make(map[string]*types.LabelCount)
+	for _, pr := range prs {
+		names := uniqueLabelNames(pr)
+		for _, name := range names {
+			count, exists := counts[name]
+			if !exists {
+				count = &types.LabelCount{Name: name}
+				counts[name] = count
+			}
+			count.Total++
+			if len(names) == 1 {
+				count.Exclusive++
+			}
+		}
+	}
+
+	labels := make([]types.LabelCount, 0, len(counts))
+	for _, count := range counts {
+		labels = append(labels, *count)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Total != labels[j].Total {
+			return labels[i].Total > labels[j].Total
+		}
+		return labels[i].Name < labels[j].Name
+	})
+	if top > 0 && len(labels) > top {
+		labels = labels[:top]
+	}
+
+	index := make(map[string]int, len(labels))
+	matrix := make([][]int, len(labels))
+	for i, label := range labels {
+		index[label.Name] = i
+		matrix[i] = make([]int, len(labels))
+	}
+
+	for _, pr := range prs {
+		names := uniqueLabelNames(pr)
+		for _, a := range names {
+			i, ok := index[a]
+			if !ok {
+				continue
+			}
+			for _, b := range names {
+				if j, ok := index[b]; ok {
+					matrix[i][j]++
+				}
+			}
+		}
+	}
+
+	pairs := make([]types.LabelPair, 0)
+	for i := range labels {
+		for j := i + 1; j < len(labels); j++ {
+			if matrix[i][j] > 0 {
+				pairs = append(pairs, types.LabelPair{
+					Labels: [2]string{labels[i].Name, labels[j].Name},
+					Count:  matrix[i][j],
+				})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Count > pairs[j].Count })
+
+	return types.LabelMatrix{
+		PullRequests: len(prs),
+		Labels:       labels,
+		Matrix:       matrix,
+		Pairs:        pairs,
+	}
+}
+
+// uniqueLabelNames returns the label names of the pr without duplicates
+func uniqueLabelNames(pr types.PullRequest) []string {
+	seen := make(map[string]bool, len(pr.Labels))
+	names := make([]string, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		if !seen[label.Name] {
+			seen[label.Name] = true
+			names = append(names, label.Name)
+		}
+	}
+	return names
+}
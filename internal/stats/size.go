@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// SizeNames are the names of the size buckets from the smallest
+var SizeNames = []string{"XS", "S", "M", "L", "XL"}
+
+// DefaultSizeThresholds are the exclusive upper bounds of changed lines of
+// every size bucket but the largest
+var DefaultSizeThresholds = []int{10, 100, 500, 1000}
+
+// ParseSizeThresholds parses a comma separated list of increasing thresholds
+func ParseSizeThresholds(spec string) ([]int, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != len(SizeNames)-1 {
+		return nil, fmt.Errorf("invalid size thresholds %q: expected %d comma separated numbers", spec, len(SizeNames)-1)
+	}
+
+	thresholds := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid size threshold %q", part)
+		}
+		if i > 0 && n <= thresholds[i-1] {
+			return nil, fmt.Errorf("invalid size thresholds %q: must be increasing", spec)
+		}
+		thresholds[i] = n
+	}
+	return thresholds, nil
+}
+
+// SizeBucket returns the index of the size bucket of the given changed lines
+func SizeBucket(lines int, thresholds []int) int {
+	for i, threshold := range thresholds {
+		if lines < threshold {
+			return i
+		}
+	}
+	return len(thresholds)
+}
+
+// daysToMerge returns the days from creation to merge, or false if not merged
+func daysToMerge(pr types.PullRequest, days DaysFunc) (float64, bool) {
+	mergedAt := pr.MergedAt()
+	if mergedAt == nil || pr.CreatedAt == nil || mergedAt.Before(*pr.CreatedAt) {
+		return 0, false
+	}
+	return days.Between(*pr.CreatedAt, *mergedAt), true
+}
+
+// CalculateSizes reports the merge statistics per size bucket and the size
+// percentiles per label. Prs without a known size are ignored.
+func CalculateSizes(prs []types.PullRequest, thresholds []int, days DaysFunc) types.SizeReport {
+	buckets := make([]types.SizeBucketStat, len(SizeNames))
+	bucketMergeTimes := make([][]float64, len(SizeNames))
+	for i, name := range SizeNames {
+		buckets[i].Name = name
+		if i < len(thresholds) {
+			buckets[i].MaxLines = thresholds[i]
+		}
+	}
+
+	type labelSizes struct {
+		lines, files, commits, mergeTimes []float64
+	}
+	labels := make(map[string]*labelSizes)
+
+	for _, pr := range prs {
+		if pr.Size == nil {
+			continue
+		}
+
+		index := SizeBucket(pr.Size.Lines(), thresholds)
+		buckets[index].Total++
+		mergeDays, merged := daysToMerge(pr, days)
+		if merged {
+			buckets[index].Merged++
+			bucketMergeTimes[index] = append(bucketMergeTimes[index], mergeDays)
+		}
+
+		names := []string{types.UnlabeledLabel}
+		if len(pr.Labels) > 0 {
+			names = names[:0]
+			for _, label := range pr.Labels {
+				names = append(names, label.Name)
+			}
+		}
+		for _, name := range names {
+			sizes, exists := labels[name]
+			if !exists {
+				sizes = &labelSizes{}
+				labels[name] = sizes
+			}
+			sizes.lines = append(sizes.lines, float64(pr.Size.Lines()))
+			sizes.files = append(sizes.files, float64(pr.Size.ChangedFiles))
+			sizes.commits = append(sizes.commits, float64(pr.Size.Commits))
+			if merged {
+				sizes.mergeTimes = append(sizes.mergeTimes, mergeDays)
+			}
+		}
+	}
+
+	for i := range buckets {
+		if buckets[i].Total > 0 {
+			buckets[i].MergeRate = float64(buckets[i].Merged) / float64(buckets[i].Total) * 100
+		}
+		buckets[i].MedianDaysToMerge = calculateMedian(bucketMergeTimes[i])
+	}
+
+	labelSizeStats := make([]types.LabelSizeStat, 0, len(labels))
+	for name, sizes := range labels {
+		labelSizeStats = append(labelSizeStats, types.LabelSizeStat{
+			Name:              name,
+			Total:             len(sizes.lines),
+			MedianLines:       calculateMedian(sizes.lines),
+			P90Lines:          calculatePercentile(sizes.lines, 90),
+			MedianFiles:       calculateMedian(sizes.files),
+			P90Files:          calculatePercentile(sizes.files, 90),
+			MedianCommits:     calculateMedian(sizes.commits),
+			MedianDaysToMerge: calculateMedian(sizes.mergeTimes),
+		})
+	}
+	sort.Slice(labelSizeStats, func(i, j int) bool {
+		if labelSizeStats[i].Total != labelSizeStats[j].Total {
+			return labelSizeStats[i].Total > labelSizeStats[j].Total
+		}
+		return labelSizeStats[i].Name < labelSizeStats[j].Name
+	})
+
+	return types.SizeReport{
+		SizeBuckets: buckets,
+		LabelSizes:  labelSizeStats,
+	}
+}
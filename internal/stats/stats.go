@@ -21,7 +21,25 @@ func calculateMedian(values []float64) float64 {
 	return values[middle]
 }
 
-func CalculateStatistics(prs []types.PullRequest) types.Statistics {
+// calculatePercentile calculates the p-th percentile (0-100) from a slice of
+// float64 using linear interpolation between the closest ranks
+func calculatePercentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sort.Float64s(values)
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(rank)
+	if lower >= len(values)-1 {
+		return values[len(values)-1]
+	}
+	return values[lower] + (rank-float64(lower))*(values[lower+1]-values[lower])
+}
+
+// CalculateStatistics computes the statistics per label and overall,
+// measuring the durations with days
+func CalculateStatistics(prs []types.PullRequest, days DaysFunc) types.Statistics {
 	labelStatsSlice := make([]types.LabelStat, 0)
 	labelStats := make(map[string]*types.LabelStat)
 	overallStats := types.OverallStats{}
@@ -37,11 +55,15 @@ func CalculateStatistics(prs []types.PullRequest) types.Statistics {
 	for _, pr := range prs {
 		// Update overall stats
 		overallStats.Total++
+		merged := pr.MergedAt() != nil
 		if pr.State == "open" {
 			overallStats.Open++
 		} else {
 			overallStats.Closed++
 		}
+		if merged {
+			overallStats.Merged++
+		}
 
 		// Update label stats
 		if len(pr.Labels) == 0 {
@@ -58,6 +80,9 @@ func CalculateStatistics(prs []types.PullRequest) types.Statistics {
 			} else {
 				stat.Closed++
 			}
+			if merged {
+				stat.Merged++
+			}
 		} else {
 			// Handle labeled prs
 			for _, label := range pr.Labels {
@@ -72,15 +97,17 @@ func CalculateStatistics(prs []types.PullRequest) types.Statistics {
 				} else {
 					stat.Closed++
 				}
+				if merged {
+					stat.Merged++
+				}
 			}
 		}
 
 		if pr.State == "closed" {
 			if pr.ClosedAt != nil && pr.CreatedAt != nil {
-				closeTime := pr.ClosedAt.Sub(*pr.CreatedAt)
-				if closeTime >= 0 {
-					closeTimeDays := closeTime.Hours() / 24 // Convert to days
-					totalCloseTime += closeTimeDays         // Store in days
+				if !pr.ClosedAt.Before(*pr.CreatedAt) {
+					closeTimeDays := days.Between(*pr.CreatedAt, *pr.ClosedAt)
+					totalCloseTime += closeTimeDays // Store in days
 					allCloseTimes = append(allCloseTimes, closeTimeDays)
 					closedPullRequestsCount++
 					if len(pr.Labels) == 0 {
@@ -108,30 +135,33 @@ func CalculateStatistics(prs []types.PullRequest) types.Statistics {
 		labelStatsSlice = append(labelStatsSlice, *stat)
 	}
 	sort.Slice(labelStatsSlice, func(i, j int) bool {
-		return labelStatsSlice[i].Total > labelStatsSlice[j].Total
+		if labelStatsSlice[i].Total != labelStatsSlice[j].Total {
+			return labelStatsSlice[i].Total > labelStatsSlice[j].Total
+		}
+		return labelStatsSlice[i].Name < labelStatsSlice[j].Name
 	})
 
 	// Calculate the average close time for each label (already in days)
 	for i, stat := range labelStatsSlice {
 		totalLabelCloseTime := labelAvgDaysToClose[stat.Name]
 
-		if stat.Total > 0 {
-			stat.OpenPercentage = float64(stat.Open) / float64(stat.Total) * 100
-		}
+		stat.OpenPercentage = openPercentage(stat.Open, stat.Total)
 
 		if stat.Closed > 0 {
 			stat.AvgDaysToClose = totalLabelCloseTime / float64(stat.Closed)
 			stat.MedianDaysToClose = calculateMedian(labelCloseTimes[stat.Name])
+			stat.P90DaysToClose = calculatePercentile(labelCloseTimes[stat.Name], 90)
 		}
 
 		labelStatsSlice[i] = stat
 	}
 
 	// Calculate the overall average close time (already in days)
-	var overallAvgDaysToClose, overallMedianDaysToClose float64
+	var overallAvgDaysToClose, overallMedianDaysToClose, overallP90DaysToClose float64
 	if closedPullRequestsCount > 0 {
 		overallAvgDaysToClose = totalCloseTime / float64(closedPullRequestsCount)
 		overallMedianDaysToClose = calculateMedian(allCloseTimes)
+		overallP90DaysToClose = calculatePercentile(allCloseTimes, 90)
 	}
 
 	return types.Statistics{
@@ -139,10 +169,20 @@ func CalculateStatistics(prs []types.PullRequest) types.Statistics {
 		OverallStats: types.OverallStats{
 			Total:             overallStats.Total,
 			Open:              overallStats.Open,
-			OpenPercentage:    float64(overallStats.Open) / float64(overallStats.Total) * 100,
+			OpenPercentage:    openPercentage(overallStats.Open, overallStats.Total),
 			Closed:            overallStats.Closed,
+			Merged:            overallStats.Merged,
 			AvgDaysToClose:    overallAvgDaysToClose,
 			MedianDaysToClose: overallMedianDaysToClose,
+			P90DaysToClose:    overallP90DaysToClose,
 		},
 	}
 }
+
+// openPercentage returns the share of open prs, 0 when there are no prs
+func openPercentage(open, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(open) / float64(total) * 100
+}
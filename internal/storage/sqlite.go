@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	_ "modernc.org/sqlite"
+)
+
+// schemaVersion is the version of the schema, stored in the user_version
+// of the database
+const schemaVersion = 1
+
+// schema creates the normalized tables. Every statement is idempotent so an
+// existing database can be reused as a history database across runs.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS pull_requests (
+		repository TEXT NOT NULL,
+		number     INTEGER NOT NULL,
+		title      TEXT NOT NULL,
+		state      TEXT NOT NULL,
+		author     TEXT,
+		created_at TIMESTAMP,
+		closed_at  TIMESTAMP,
+		merged_at  TIMESTAMP,
+		PRIMARY KEY (repository, number)
+	)`,
+	`CREATE TABLE IF NOT EXISTS labels (
+		repository TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		PRIMARY KEY (repository, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS pull_request_labels (
+		repository TEXT NOT NULL,
+		number     INTEGER NOT NULL,
+		label      TEXT NOT NULL,
+		PRIMARY KEY (repository, number, label),
+		FOREIGN KEY (repository, number) REFERENCES pull_requests (repository, number),
+		FOREIGN KEY (repository, label) REFERENCES labels (repository, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS reviews (
+		id           INTEGER PRIMARY KEY,
+		repository   TEXT NOT NULL,
+		number       INTEGER NOT NULL,
+		reviewer     TEXT,
+		state        TEXT NOT NULL,
+		submitted_at TIMESTAMP,
+		FOREIGN KEY (repository, number) REFERENCES pull_requests (repository, number)
+	)`,
+	// Every run adds a snapshot of the statistics, so they can be queried
+	// over time
+	`CREATE TABLE IF NOT EXISTS runs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		repository  TEXT NOT NULL,
+		computed_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS label_stats (
+		run_id               INTEGER NOT NULL,
+		repository           TEXT NOT NULL,
+		label                TEXT NOT NULL,
+		open                 INTEGER NOT NULL,
+		closed               INTEGER NOT NULL,
+		merged               INTEGER,
+		total                INTEGER NOT NULL,
+		open_percentage      REAL NOT NULL,
+		avg_days_to_close    REAL NOT NULL,
+		median_days_to_close REAL NOT NULL,
+		p90_days_to_close    REAL,
+		computed_at          TIMESTAMP NOT NULL,
+		PRIMARY KEY (run_id, label),
+		FOREIGN KEY (run_id) REFERENCES runs (id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS overall_stats (
+		run_id               INTEGER PRIMARY KEY,
+		repository           TEXT NOT NULL,
+		open                 INTEGER NOT NULL,
+		closed               INTEGER NOT NULL,
+		merged               INTEGER,
+		total                INTEGER NOT NULL,
+		open_percentage      REAL NOT NULL,
+		avg_days_to_close    REAL NOT NULL,
+		median_days_to_close REAL NOT NULL,
+		p90_days_to_close    REAL,
+		computed_at          TIMESTAMP NOT NULL,
+		FOREIGN KEY (run_id) REFERENCES runs (id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS runs_repository ON runs (repository, computed_at)`,
+	// The statistics of the last run of every repository
+	`CREATE VIEW IF NOT EXISTS latest_label_stats AS
+		SELECT label_stats.* FROM label_stats
+		WHERE run_id IN (SELECT MAX(id) FROM runs GROUP BY repository)`,
+	`CREATE VIEW IF NOT EXISTS latest_overall_stats AS
+		SELECT overall_stats.* FROM overall_stats
+		WHERE run_id IN (SELECT MAX(id) FROM runs GROUP BY repository)`,
+}
+
+// migrate creates the schema, refusing databases of a later version
+func migrate(tx *sql.Tx) error {
+	var version int
+	if err := tx.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("unsupported schema version %d, this version writes up to %d", version, schemaVersion)
+	}
+
+	for _, stmt := range schema {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create schema: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersion)); err != nil {
+		return fmt.Errorf("failed to write schema version: %v", err)
+	}
+	return nil
+}
+
+// SaveToSQLite upserts the prs, their labels and reviews and the computed
+// statistics of a repository into the SQLite database at filename
+func SaveToSQLite(filename, repository string, prs []types.PullRequest, reviews map[int][]types.Review, stats types.Statistics, progress *utils.Progress) error {
+	progress.Start(fmt.Sprintf(" Saving to %s...", filename))
+	defer progress.Stop()
+
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return fmt.Errorf("failed to open database %s: %v", filename, err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := migrate(tx); err != nil {
+		return err
+	}
+
+	if err := savePullRequests(tx, repository, prs); err != nil {
+		return err
+	}
+	if err := saveReviews(tx, repository, reviews); err != nil {
+		return err
+	}
+	if err := saveStatistics(tx, repository, stats); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit to %s: %v", filename, err)
+	}
+
+	progress.Debugf("Data saved to %s", filename)
+	return nil
+}
+
+func savePullRequests(tx *sql.Tx, repository string, prs []types.PullRequest) error {
+	for _, pr := range prs {
+		_, err := tx.Exec(`INSERT INTO pull_requests (repository, number, title, state, author, created_at, closed_at, merged_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (repository, number) DO UPDATE SET
+				title = excluded.title,
+				state = excluded.state,
+				author = excluded.author,
+				created_at = excluded.created_at,
+				closed_at = excluded.closed_at,
+				merged_at = excluded.merged_at`,
+			repository, pr.Number, pr.Title, pr.State, pr.User.Login, nullTime(pr.CreatedAt), nullTime(pr.ClosedAt), nullTime(pr.MergedAt()))
+		if err != nil {
+			return fmt.Errorf("failed to save pr #%d: %v", pr.Number, err)
+		}
+
+		// Labels can be removed from a pr, so replace the whole set
+		if _, err := tx.Exec(`DELETE FROM pull_request_labels WHERE repository = ? AND number = ?`, repository, pr.Number); err != nil {
+			return fmt.Errorf("failed to clear labels of pr #%d: %v", pr.Number, err)
+		}
+		for _, label := range pr.Labels {
+			if _, err := tx.Exec(`INSERT INTO labels (repository, name) VALUES (?, ?) ON CONFLICT DO NOTHING`, repository, label.Name); err != nil {
+				return fmt.Errorf("failed to save label %s: %v", label.Name, err)
+			}
+			if _, err := tx.Exec(`INSERT INTO pull_request_labels (repository, number, label) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+				repository, pr.Number, label.Name); err != nil {
+				return fmt.Errorf("failed to save label %s of pr #%d: %v", label.Name, pr.Number, err)
+			}
+		}
+	}
+	return nil
+}
+
+func saveReviews(tx *sql.Tx, repository string, reviews map[int][]types.Review) error {
+	for number, prReviews := range reviews {
+		for _, review := range prReviews {
+			_, err := tx.Exec(`INSERT INTO reviews (id, repository, number, reviewer, state, submitted_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					reviewer = excluded.reviewer,
+					state = excluded.state,
+					submitted_at = excluded.submitted_at`,
+				review.ID, repository, number, review.User.Login, review.State, nullTime(review.SubmittedAt))
+			if err != nil {
+				return fmt.Errorf("failed to save review %d of pr #%d: %v", review.ID, number, err)
+			}
+		}
+	}
+	return nil
+}
+
+// saveStatistics adds the statistics as a new run of the repository
+func saveStatistics(tx *sql.Tx, repository string, stats types.Statistics) error {
+	computedAt := time.Now().UTC()
+
+	result, err := tx.Exec(`INSERT INTO runs (repository, computed_at) VALUES (?, ?)`, repository, computedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save run: %v", err)
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to save run: %v", err)
+	}
+
+	for _, stat := range stats.LabelStats {
+		_, err := tx.Exec(`INSERT INTO label_stats (run_id, repository, label, open, closed, merged, total, open_percentage, avg_days_to_close, median_days_to_close, p90_days_to_close, computed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			runID, repository, stat.Name, stat.Open, stat.Closed, stat.Merged, stat.Total, stat.OpenPercentage, stat.AvgDaysToClose, stat.MedianDaysToClose, stat.P90DaysToClose, computedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save statistics of label %s: %v", stat.Name, err)
+		}
+	}
+
+	overall := stats.OverallStats
+	_, err = tx.Exec(`INSERT INTO overall_stats (run_id, repository, open, closed, merged, total, open_percentage, avg_days_to_close, median_days_to_close, p90_days_to_close, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		runID, repository, overall.Open, overall.Closed, overall.Merged, overall.Total, overall.OpenPercentage, overall.AvgDaysToClose, overall.MedianDaysToClose, overall.P90DaysToClose, computedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save overall statistics: %v", err)
+	}
+	return nil
+}
+
+// nullTime converts an optional time to a value the driver can store
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
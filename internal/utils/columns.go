@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Row is a single line of the statistics output, either a label or the total
+type Row struct {
+	Name              string
+	Open              int
+	Closed            int
+	Merged            int
+	Total             int
+	OpenPercentage    float64
+	AvgDaysToClose    float64
+	MedianDaysToClose float64
+	P90DaysToClose    float64
+}
+
+// Column describes an output column shared by every statistics renderer
+type Column struct {
+	Name        string
+	Header      string
+	Description string
+	// Value formats the cell for human readable output such as tables
+	Value func(Row) string
+	// Raw formats the cell for machine readable output such as CSV
+	Raw func(Row) string
+	// Key is the numeric value used for sorting. Rows are sorted by name when nil.
+	Key func(Row) float64
+}
+
+// AvailableColumns is the registry of every column that can be selected
+var AvailableColumns = []Column{
+	{
+		Name: "label", Header: "Label", Description: "Label name",
+		Value: func(r Row) string { return r.Name },
+	},
+	intColumn("open", "Open", "Number of open prs", func(r Row) int { return r.Open }),
+	intColumn("closed", "Closed", "Number of closed prs", func(r Row) int { return r.Closed }),
+	intColumn("merged", "Merged", "Number of merged prs", func(r Row) int { return r.Merged }),
+	intColumn("total", "Total", "Number of prs", func(r Row) int { return r.Total }),
+	{
+		Name: "open_pct", Header: "Open %", Description: "Percentage of open prs",
+		Value: func(r Row) string { return fmt.Sprintf("%.2f%%", r.OpenPercentage) },
+		Raw:   func(r Row) string { return fmt.Sprintf("%.2f", r.OpenPercentage) },
+		Key:   func(r Row) float64 { return r.OpenPercentage },
+	},
+	daysColumn("avg", "Average Time to close (days)", "Average days to close", func(r Row) float64 { return r.AvgDaysToClose }),
+	daysColumn("median", "Median Time to close (days)", "Median days to close", func(r Row) float64 { return r.MedianDaysToClose }),
+	daysColumn("p90", "P90 Time to close (days)", "90th percentile of days to close", func(r Row) float64 { return r.P90DaysToClose }),
+}
+
+// DefaultColumns are the columns rendered when no selection is given
+var DefaultColumns = []string{"label", "open", "closed", "total", "open_pct", "avg", "median"}
+
+func intColumn(name, header, description string, value func(Row) int) Column {
+	return Column{
+		Name: name, Header: header, Description: description,
+		Value: func(r Row) string { return strconv.Itoa(value(r)) },
+		Key:   func(r Row) float64 { return float64(value(r)) },
+	}
+}
+
+func daysColumn(name, header, description string, value func(Row) float64) Column {
+	return Column{
+		Name: name, Header: header, Description: description,
+		Value: func(r Row) string { return fmt.Sprintf("%.0f", value(r)) },
+		Key:   value,
+	}
+}
+
+// RawValue formats the cell for machine readable output
+func (c Column) RawValue(r Row) string {
+	if c.Raw != nil {
+		return c.Raw(r)
+	}
+	return c.Value(r)
+}
+
+// LookupColumn returns the registered column with the given name
+func LookupColumn(name string) (Column, bool) {
+	for _, column := range AvailableColumns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// ParseColumns parses a comma separated list of column names. An empty spec
+// selects the default columns.
+func ParseColumns(spec string) ([]Column, error) {
+	names := DefaultColumns
+	if strings.TrimSpace(spec) != "" {
+		names = strings.Split(spec, ",")
+	}
+
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		column, ok := LookupColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q. Use --list-columns to see the available columns", name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// ParseSortBy parses a sort spec of the form <column>[:asc|desc] into a less
+// function over label statistics. Numeric columns sort descending and the
+// label column ascending unless a direction is given.
+func ParseSortBy(spec string) (func(a, b types.LabelStat) bool, error) {
+	name, direction, _ := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+
+	column, ok := LookupColumn(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown sort column %q. Use --list-columns to see the available columns", name)
+	}
+
+	desc := column.Key != nil
+	switch direction {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		return nil, fmt.Errorf("invalid sort direction %q. Expected asc or desc", direction)
+	}
+
+	return func(a, b types.LabelStat) bool {
+		ra, rb := LabelRow(a), LabelRow(b)
+		if column.Key == nil {
+			if desc {
+				return ra.Name > rb.Name
+			}
+			return ra.Name < rb.Name
+		}
+		if desc {
+			return column.Key(ra) > column.Key(rb)
+		}
+		return column.Key(ra) < column.Key(rb)
+	}, nil
+}
+
+// LabelRow converts label statistics to an output row
+func LabelRow(stat types.LabelStat) Row {
+	return Row{
+		Name:              stat.Name,
+		Open:              stat.Open,
+		Closed:            stat.Closed,
+		Merged:            stat.Merged,
+		Total:             stat.Total,
+		OpenPercentage:    stat.OpenPercentage,
+		AvgDaysToClose:    stat.AvgDaysToClose,
+		MedianDaysToClose: stat.MedianDaysToClose,
+		P90DaysToClose:    stat.P90DaysToClose,
+	}
+}
+
+// TotalRow converts the overall statistics to an output row
+func TotalRow(stats types.OverallStats) Row {
+	return Row{
+		Name:              "Total",
+		Open:              stats.Open,
+		Closed:            stats.Closed,
+		Merged:            stats.Merged,
+		Total:             stats.Total,
+		OpenPercentage:    stats.OpenPercentage,
+		AvgDaysToClose:    stats.AvgDaysToClose,
+		MedianDaysToClose: stats.MedianDaysToClose,
+		P90DaysToClose:    stats.P90DaysToClose,
+	}
+}
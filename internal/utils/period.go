@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsePeriod parses a period such as 30d, 2w or any Go duration like 12h
+func ParsePeriod(period string) (time.Duration, error) {
+	period = strings.TrimSpace(period)
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(period, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("invalid period %q", period)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid period %q. Expected e.g. 30d, 2w or 12h", period)
+	}
+	return d, nil
+}
+
+// ParseSince parses the start of a date window, either a date such as
+// 2024-01-01 or a period such as 30d counted back from now
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", strings.TrimSpace(since)); err == nil {
+		return date, nil
+	}
+
+	period, err := ParsePeriod(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q. Expected a date such as 2024-01-01 or a period such as 30d", since)
+	}
+	return now.Add(-period), nil
+}
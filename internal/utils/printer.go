@@ -4,22 +4,21 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/shufo/gh-pr-stats/internal/check"
+	"github.com/shufo/gh-pr-stats/internal/compare"
 	"github.com/shufo/gh-pr-stats/pkg/types"
 	"github.com/spf13/cobra"
 )
 
-var header = []string{"Label", "Open", "Closed", "Total", "Open %", "Average Time to close (days)", "Median Time to close (days)"}
-
-func PrintStatistics(cmd *cobra.Command, stats types.Statistics) {
+// PrintStatistics writes the statistics as a table to w
+func PrintStatistics(w io.Writer, stats types.Statistics, columns []Column) {
 	t := table.NewWriter()
-	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetOutputMirror(w)
 	t.SetStyle(table.StyleRounded)
 
 	// Configure table style
@@ -28,56 +27,58 @@ func PrintStatistics(cmd *cobra.Command, stats types.Statistics) {
 	t.Style().Options.SeparateHeader = true
 	t.Style().Options.SeparateRows = false
 
-	// Set header
-	row := make(table.Row, len(header))
-	for i, v := range header {
-		row[i] = v
+	// Set header, aligning every value column to the right
+	row := make(table.Row, len(columns))
+	configs := make([]table.ColumnConfig, 0, len(columns))
+	for i, column := range columns {
+		row[i] = column.Header
+		if column.Name != "label" {
+			configs = append(configs, table.ColumnConfig{Number: i + 1, Align: text.AlignRight})
+		}
 	}
 	t.AppendHeader(row)
+	t.SetColumnConfigs(configs)
 
 	// Add label statistics rows
 	for _, stat := range stats.LabelStats {
-		t.AppendRow(table.Row{
-			stat.Name,
-			stat.Open,
-			stat.Closed,
-			stat.Total,
-			fmt.Sprintf("%.2f%%", stat.OpenPercentage),
-			fmt.Sprintf("%.0f", stat.AvgDaysToClose),
-			fmt.Sprintf("%.0f", stat.MedianDaysToClose),
-		})
+		t.AppendRow(tableRow(LabelRow(stat), columns))
 	}
 
 	// Add separator and total row
 	t.AppendSeparator()
-	t.AppendRow(table.Row{
-		"Total",
-		stats.OverallStats.Open,
-		stats.OverallStats.Closed,
-		stats.OverallStats.Total,
-		fmt.Sprintf("%.2f%%", stats.OverallStats.OpenPercentage),
-		fmt.Sprintf("%.0f", stats.OverallStats.AvgDaysToClose),
-		fmt.Sprintf("%.0f", stats.OverallStats.MedianDaysToClose),
-	})
+	t.AppendRow(tableRow(TotalRow(stats.OverallStats), columns))
 
 	// Render the table
 	t.Render()
 }
 
-func SaveToFile(data interface{}, filename string) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = fmt.Sprintf(" Saving to %s...", filename)
-	if !debug {
-		s.Start()
+func tableRow(r Row, columns []Column) table.Row {
+	row := make(table.Row, len(columns))
+	for i, column := range columns {
+		row[i] = column.Value(r)
+	}
+	return row
+}
+
+// PrintColumns lists the available columns
+func PrintColumns(cmd *cobra.Command) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Column", "Header", "Description"})
+	for _, column := range AvailableColumns {
+		t.AppendRow(table.Row{column.Name, column.Header, column.Description})
 	}
+	t.Render()
+}
 
-	StartSpinner(fmt.Sprintf(" Saving to %s...", filename))
+// SaveToFile writes data as indented JSON to filename
+func SaveToFile(data interface{}, filename string, progress *Progress) error {
+	progress.Start(fmt.Sprintf(" Saving to %s...", filename))
+	defer progress.Stop()
 
 	file, err := os.Create(filename)
 	if err != nil {
-		if !debug {
-			StopSpinner()
-		}
 		return fmt.Errorf("failed to create file %s: %v", filename, err)
 	}
 	defer file.Close()
@@ -85,60 +86,386 @@ func SaveToFile(data interface{}, filename string) error {
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(data); err != nil {
-		if !debug {
-			s.Stop()
-		}
 		return fmt.Errorf("failed to write to file %s: %v", filename, err)
 	}
 
-	if !debug {
-		StopSpinner()
-	}
-
-	DebugPrintf("Data saved to %s", filename)
-
+	progress.Debugf("Data saved to %s", filename)
 	return nil
 }
 
-func WriteDelimitedOutput(cmd *cobra.Command, stats types.Statistics, delimiter rune) error {
-	writer := csv.NewWriter(cmd.OutOrStdout())
+// WriteDelimitedOutput writes the statistics as CSV or TSV to w
+func WriteDelimitedOutput(w io.Writer, stats types.Statistics, delimiter rune, columns []Column) error {
+	writer := csv.NewWriter(w)
 	writer.Comma = delimiter
 
 	// Write header
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("error writing header: %v", err)
 	}
 
 	// Write label statistics
 	for _, stat := range stats.LabelStats {
-		row := []string{
-			stat.Name,
-			strconv.Itoa(stat.Open),
-			strconv.Itoa(stat.Closed),
-			strconv.Itoa(stat.Total),
-			fmt.Sprintf("%.2f", stat.OpenPercentage),
-			fmt.Sprintf("%.0f", stat.AvgDaysToClose),
-			fmt.Sprintf("%.0f", stat.MedianDaysToClose),
-		}
-		if err := writer.Write(row); err != nil {
+		if err := writer.Write(rawRow(LabelRow(stat), columns)); err != nil {
 			return fmt.Errorf("error writing row: %v", err)
 		}
 	}
 
 	// Write total row
-	totalRow := []string{
-		"Total",
-		strconv.Itoa(stats.OverallStats.Open),
-		strconv.Itoa(stats.OverallStats.Closed),
-		strconv.Itoa(stats.OverallStats.Total),
-		fmt.Sprintf("%.2f%%", stats.OverallStats.OpenPercentage),
-		fmt.Sprintf("%.0f", stats.OverallStats.AvgDaysToClose),
-		fmt.Sprintf("%.0f", stats.OverallStats.MedianDaysToClose),
-	}
-	if err := writer.Write(totalRow); err != nil {
+	if err := writer.Write(rawRow(TotalRow(stats.OverallStats), columns)); err != nil {
 		return fmt.Errorf("error writing total row: %v", err)
 	}
 
 	writer.Flush()
 	return writer.Error()
 }
+
+func rawRow(r Row, columns []Column) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = column.RawValue(r)
+	}
+	return row
+}
+
+// WriteMarkdownOutput writes the statistics as a GitHub flavored markdown table
+func WriteMarkdownOutput(w io.Writer, stats types.Statistics, columns []Column) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+
+	row := make(table.Row, len(columns))
+	configs := make([]table.ColumnConfig, 0, len(columns))
+	for i, column := range columns {
+		row[i] = column.Header
+		if column.Name != "label" {
+			configs = append(configs, table.ColumnConfig{Number: i + 1, Align: text.AlignRight})
+		}
+	}
+	t.AppendHeader(row)
+	t.SetColumnConfigs(configs)
+
+	for _, stat := range stats.LabelStats {
+		t.AppendRow(tableRow(LabelRow(stat), columns))
+	}
+	t.AppendRow(tableRow(TotalRow(stats.OverallStats), columns))
+
+	t.RenderMarkdown()
+}
+
+// PrintCheckResults prints whether each threshold rule passed or failed
+func PrintCheckResults(cmd *cobra.Command, results []check.Result) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.Style().Format.Header = text.FormatTitle
+	t.AppendHeader(table.Row{"Rule", "Value", "Result"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Number: 2, Align: text.AlignRight}})
+
+	for _, result := range results {
+		status := text.FgGreen.Sprint("PASS")
+		if result.Failed {
+			status = text.FgRed.Sprint("FAIL")
+		}
+		value := fmt.Sprintf("%.2f", result.Value)
+		if result.Undefined {
+			value = "n/a"
+		}
+		t.AppendRow(table.Row{result.Rule.Expression, value, status})
+	}
+
+	t.Render()
+}
+
+// PrintComparison prints the head value of every metric with its change
+// from base, colored by whether the change is an improvement
+func PrintComparison(cmd *cobra.Command, comparison compare.Comparison) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.Style().Format.Header = text.FormatTitle
+
+	header := table.Row{"Label"}
+	configs := make([]table.ColumnConfig, 0, len(compare.Metrics))
+	for i, metric := range compare.Metrics {
+		header = append(header, metric.Header)
+		configs = append(configs, table.ColumnConfig{Number: i + 2, Align: text.AlignRight})
+	}
+	t.AppendHeader(header)
+	t.SetColumnConfigs(configs)
+
+	for _, diff := range comparison.Labels {
+		t.AppendRow(comparisonRow(diff))
+	}
+	t.AppendSeparator()
+	t.AppendRow(comparisonRow(comparison.Overall))
+
+	t.Render()
+}
+
+func comparisonRow(diff compare.Diff) table.Row {
+	row := table.Row{diff.Name}
+	for _, metric := range compare.Metrics {
+		delta := metric.Get(diff)
+
+		arrow, color := "=", text.Colors{}
+		switch {
+		case delta.Delta > 0:
+			arrow = "▲"
+		case delta.Delta < 0:
+			arrow = "▼"
+		}
+		if metric.HigherIsBetter != nil && delta.Delta != 0 {
+			if (delta.Delta > 0) == *metric.HigherIsBetter {
+				color = text.Colors{text.FgGreen}
+			} else {
+				color = text.Colors{text.FgRed}
+			}
+		}
+
+		change := fmt.Sprintf("%+.*f", metric.Precision, delta.Delta)
+		if delta.PercentChange != nil {
+			change += fmt.Sprintf(", %+.1f%%", *delta.PercentChange)
+		}
+		row = append(row, fmt.Sprintf("%.*f %s", metric.Precision, delta.Head, color.Sprintf("%s %s", arrow, change)))
+	}
+	return row
+}
+
+// WriteDelimitedComparison writes the base, head and signed delta of every metric
+func WriteDelimitedComparison(cmd *cobra.Command, comparison compare.Comparison, delimiter rune) error {
+	writer := csv.NewWriter(cmd.OutOrStdout())
+	writer.Comma = delimiter
+
+	header := []string{"Label"}
+	for _, metric := range compare.Metrics {
+		header = append(header,
+			metric.Name+"_base",
+			metric.Name+"_head",
+			metric.Name+"_delta",
+			metric.Name+"_delta_pct",
+		)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("error writing header: %v", err)
+	}
+
+	diffs := append(append([]compare.Diff(nil), comparison.Labels...), comparison.Overall)
+	for _, diff := range diffs {
+		row := []string{diff.Name}
+		for _, metric := range compare.Metrics {
+			delta := metric.Get(diff)
+			pct := ""
+			if delta.PercentChange != nil {
+				pct = fmt.Sprintf("%+.2f", *delta.PercentChange)
+			}
+			row = append(row,
+				fmt.Sprintf("%.2f", delta.Base),
+				fmt.Sprintf("%.2f", delta.Head),
+				fmt.Sprintf("%+.2f", delta.Delta),
+				pct,
+			)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// PrintAgingReport prints the age distribution of open prs per label and the oldest open prs
+func PrintAgingReport(cmd *cobra.Command, report types.AgingReport) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.Style().Format.Header = text.FormatTitle
+
+	header := table.Row{"Label"}
+	for _, bucket := range report.OverallAging.Buckets {
+		header = append(header, bucket.Name)
+	}
+	header = append(header, "Open", "Stale")
+	t.AppendHeader(header)
+
+	agingRow := func(aging types.LabelAging) table.Row {
+		row := table.Row{aging.Name}
+		for _, bucket := range aging.Buckets {
+			row = append(row, bucket.Count)
+		}
+		return append(row, aging.Open, aging.Stale)
+	}
+	for _, aging := range report.LabelAging {
+		t.AppendRow(agingRow(aging))
+	}
+	t.AppendSeparator()
+	t.AppendRow(agingRow(report.OverallAging))
+	t.Render()
+
+	if len(report.Oldest) == 0 {
+		return
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	o := table.NewWriter()
+	o.SetOutputMirror(cmd.OutOrStdout())
+	o.SetStyle(table.StyleRounded)
+	o.Style().Format.Header = text.FormatTitle
+	o.AppendHeader(table.Row{"#", "Title", "Author", "Age (days)", "Last activity", "Stale"})
+	for _, pr := range report.Oldest {
+		stale := ""
+		if pr.Stale {
+			stale = text.FgRed.Sprint("stale")
+		}
+		o.AppendRow(table.Row{
+			pr.Number,
+			text.Trim(pr.Title, 60),
+			pr.Author,
+			fmt.Sprintf("%.0f", pr.AgeDays),
+			formatTime(pr.LastActivity),
+			stale,
+		})
+	}
+	o.Render()
+}
+
+// PrintSizeReport prints the merge statistics per size bucket and the size percentiles per label
+func PrintSizeReport(cmd *cobra.Command, report types.SizeReport) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.Style().Format.Header = text.FormatTitle
+	t.AppendHeader(table.Row{"Size", "Lines", "Total", "Merged", "Merge %", "Median Time to merge (days)"})
+
+	lower := 0
+	for _, bucket := range report.SizeBuckets {
+		lines := fmt.Sprintf("%d-%d", lower, bucket.MaxLines-1)
+		if bucket.MaxLines == 0 {
+			lines = fmt.Sprintf("%d+", lower)
+		}
+		lower = bucket.MaxLines
+		t.AppendRow(table.Row{
+			bucket.Name,
+			lines,
+			bucket.Total,
+			bucket.Merged,
+			fmt.Sprintf("%.2f%%", bucket.MergeRate),
+			fmt.Sprintf("%.1f", bucket.MedianDaysToMerge),
+		})
+	}
+	t.Render()
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	l := table.NewWriter()
+	l.SetOutputMirror(cmd.OutOrStdout())
+	l.SetStyle(table.StyleRounded)
+	l.Style().Format.Header = text.FormatTitle
+	l.AppendHeader(table.Row{"Label", "Total", "Median lines", "P90 lines", "Median files", "P90 files", "Median commits", "Median Time to merge (days)"})
+	for _, stat := range report.LabelSizes {
+		l.AppendRow(table.Row{
+			stat.Name,
+			stat.Total,
+			fmt.Sprintf("%.0f", stat.MedianLines),
+			fmt.Sprintf("%.0f", stat.P90Lines),
+			fmt.Sprintf("%.0f", stat.MedianFiles),
+			fmt.Sprintf("%.0f", stat.P90Files),
+			fmt.Sprintf("%.0f", stat.MedianCommits),
+			fmt.Sprintf("%.1f", stat.MedianDaysToMerge),
+		})
+	}
+	l.Render()
+}
+
+// PrintInsightsReport prints the correlations of pr attributes with time to merge
+func PrintInsightsReport(cmd *cobra.Command, report types.InsightsReport) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.Style().Format.Header = text.FormatTitle
+	t.SetTitle(fmt.Sprintf("Correlation with time to merge (%d merged prs)", report.MergedPullRequests))
+	t.AppendHeader(table.Row{"Attribute", "Spearman", "Strength", "Samples"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Number: 2, Align: text.AlignRight}})
+
+	for _, correlation := range report.Correlations {
+		coefficient := "-"
+		if correlation.Strength != "n/a" {
+			coefficient = fmt.Sprintf("%+.3f", correlation.Coefficient)
+		}
+		t.AppendRow(table.Row{correlation.Attribute, coefficient, correlation.Strength, correlation.Samples})
+	}
+
+	t.Render()
+}
+
+// PrintLabelMatrix prints the label co-occurrence matrix and the label pairs
+// ordered by how often they are used together
+func PrintLabelMatrix(cmd *cobra.Command, matrix types.LabelMatrix) {
+	t := table.NewWriter()
+	t.SetOutputMirror(cmd.OutOrStdout())
+	t.SetStyle(table.StyleRounded)
+	t.SetTitle(fmt.Sprintf("Label co-occurrence (%d prs)", matrix.PullRequests))
+
+	header := table.Row{"Label", "Total", "Exclusive"}
+	for _, label := range matrix.Labels {
+		header = append(header, label.Name)
+	}
+	t.AppendHeader(header)
+
+	for i, label := range matrix.Labels {
+		row := table.Row{label.Name, label.Total, label.Exclusive}
+		for j, count := range matrix.Matrix[i] {
+			if i == j {
+				row = append(row, "-")
+			} else {
+				row = append(row, count)
+			}
+		}
+		t.AppendRow(row)
+	}
+	t.Render()
+
+	if len(matrix.Pairs) == 0 {
+		return
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	p := table.NewWriter()
+	p.SetOutputMirror(cmd.OutOrStdout())
+	p.SetStyle(table.StyleRounded)
+	p.Style().Format.Header = text.FormatTitle
+	p.AppendHeader(table.Row{"Label", "Label", "Prs"})
+	for _, pair := range matrix.Pairs {
+		p.AppendRow(table.Row{pair.Labels[0], pair.Labels[1], pair.Count})
+	}
+	p.Render()
+}
+
+// WriteDelimitedLabelMatrix writes the label co-occurrence matrix as CSV or TSV
+func WriteDelimitedLabelMatrix(cmd *cobra.Command, matrix types.LabelMatrix, delimiter rune) error {
+	writer := csv.NewWriter(cmd.OutOrStdout())
+	writer.Comma = delimiter
+
+	header := []string{"Label", "Total", "Exclusive"}
+	for _, label := range matrix.Labels {
+		header = append(header, label.Name)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("error writing header: %v", err)
+	}
+
+	for i, label := range matrix.Labels {
+		row := []string{label.Name, fmt.Sprint(label.Total), fmt.Sprint(label.Exclusive)}
+		for _, count := range matrix.Matrix[i] {
+			row = append(row, fmt.Sprint(count))
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"golang.org/x/exp/slog"
+)
+
+// Progress shows a spinner while a long running step runs and logs the
+// debug output. A nil Progress reports nothing.
+type Progress struct {
+	// Logger receives the debug output, none when nil
+	Logger *slog.Logger
+	// Spinner shows the spinner. It is disabled in debug mode so that it
+	// does not interleave with the log lines.
+	Spinner bool
+
+	spin *spinner.Spinner
+}
+
+// Start shows the spinner with the suffix on stderr, keeping stdout for
+// the output
+func (p *Progress) Start(suffix string) {
+	if p == nil || !p.Spinner {
+		return
+	}
+	p.spin = spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(os.Stderr))
+	p.spin.Suffix = suffix
+	p.spin.Start()
+}
+
+// Update replaces the suffix of the running spinner
+func (p *Progress) Update(suffix string) {
+	if p == nil || p.spin == nil {
+		return
+	}
+	p.spin.Suffix = suffix
+}
+
+// Stop hides the running spinner
+func (p *Progress) Stop() {
+	if p == nil || p.spin == nil {
+		return
+	}
+	p.spin.Stop()
+	p.spin = nil
+}
+
+// Debugf logs a debug message
+func (p *Progress) Debugf(format string, a ...interface{}) {
+	if p != nil && p.Logger != nil {
+		p.Logger.Debug(fmt.Sprintf(format, a...))
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// RawFormats lists the supported formats of the raw prs data
+var RawFormats = []string{"json", "jsonl", "csv", "tsv"}
+
+var rawHeader = []string{"Number", "Title", "Author", "State", "Created", "Closed", "Merged", "Labels", "Time to close (days)"}
+
+// IsValidRawFormat reports whether format is a supported raw data format
+func IsValidRawFormat(format string) bool {
+	for _, f := range RawFormats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveOptions configures how the raw prs are saved
+type SaveOptions struct {
+	// Format is one of RawFormats, json when empty
+	Format string
+	// Days measures the days to close of the delimited formats
+	Days stats.DaysFunc
+	// Progress reports the saving, nothing when nil
+	Progress *Progress
+}
+
+// SavePullRequests writes the raw prs to filename in the format of the options
+func SavePullRequests(prs []types.PullRequest, filename string, opts SaveOptions) error {
+	format := strings.ToLower(opts.Format)
+	if format == "" || format == "json" {
+		return SaveToFile(prs, filename, opts.Progress)
+	}
+
+	opts.Progress.Start(fmt.Sprintf(" Saving to %s...", filename))
+	defer opts.Progress.Stop()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	switch format {
+	case "jsonl":
+		err = writeJSONLines(file, prs)
+	case "csv":
+		err = writeDelimitedPullRequests(file, prs, ',', opts.Days)
+	case "tsv":
+		err = writeDelimitedPullRequests(file, prs, '\t', opts.Days)
+	default:
+		err = fmt.Errorf("unsupported format %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write to file %s: %v", filename, err)
+	}
+
+	opts.Progress.Debugf("Data saved to %s", filename)
+	return nil
+}
+
+// LoadPullRequests reads raw prs saved in the json or jsonl format
+func LoadPullRequests(filename string, progress *Progress) ([]types.PullRequest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filename, err)
+	}
+
+	var prs []types.PullRequest
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] == '[' {
+		if len(trimmed) > 0 {
+			err = json.Unmarshal(trimmed, &prs)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for decoder.More() {
+			var pr types.PullRequest
+			if err = decoder.Decode(&pr); err != nil {
+				break
+			}
+			prs = append(prs, pr)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prs in %s: expected the json or jsonl format: %v", filename, err)
+	}
+
+	progress.Debugf("Loaded %d prs from %s", len(prs), filename)
+	return prs, nil
+}
+
+func writeJSONLines(w io.Writer, prs []types.PullRequest) error {
+	encoder := json.NewEncoder(w)
+	for _, pr := range prs {
+		if err := encoder.Encode(pr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDelimitedPullRequests(w io.Writer, prs []types.PullRequest, delimiter rune, days stats.DaysFunc) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	if err := writer.Write(rawHeader); err != nil {
+		return fmt.Errorf("error writing header: %v", err)
+	}
+
+	for _, pr := range prs {
+		labels := make([]string, len(pr.Labels))
+		for i, label := range pr.Labels {
+			labels[i] = label.Name
+		}
+
+		daysToClose := ""
+		if pr.State == "closed" && pr.ClosedAt != nil && pr.CreatedAt != nil {
+			daysToClose = fmt.Sprintf("%.2f", days.Between(*pr.CreatedAt, *pr.ClosedAt))
+		}
+
+		row := []string{
+			strconv.Itoa(pr.Number),
+			pr.Title,
+			pr.User.Login,
+			pr.State,
+			formatTime(pr.CreatedAt),
+			formatTime(pr.ClosedAt),
+			formatTime(pr.MergedAt()),
+			strings.Join(labels, ";"),
+			daysToClose,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
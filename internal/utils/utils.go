@@ -1,7 +0,0 @@
-package utils
-
-var debug bool
-
-func SetDebug(d bool) {
-	debug = d
-}
@@ -0,0 +1,94 @@
+package prstats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/analysis"
+	"github.com/shufo/gh-pr-stats/internal/filter"
+	"github.com/shufo/gh-pr-stats/internal/labels"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// AnalyzeOptions selects, groups and arranges the prs. The zero value
+// computes the statistics of every pr grouped by label.
+type AnalyzeOptions struct {
+	// Filter is a filter expression such as `label:bug AND NOT is:bot`.
+	// Base branch terms are not supported as prs do not carry their base.
+	Filter string
+	// ExcludeAuthors drops the prs of these logins
+	ExcludeAuthors []string
+	ExcludeBots    bool
+	OnlyBots       bool
+
+	// Labels normalizes the label names before aggregation
+	Labels LabelOptions
+
+	// GroupBy is one of label (default), author, author-type or primary-label
+	GroupBy string
+	// LabelPriority is the priority list of the primary-label group
+	LabelPriority []string
+
+	// SortBy sorts the rows by column, e.g. median:desc
+	SortBy string
+	// Top keeps the top rows and aggregates the rest into an "*other*" row
+	Top int
+	// MinCount hides rows with fewer prs
+	MinCount int
+
+	// Days measures the time between two instants in days, e.g. in working
+	// days of a business calendar. Calendar days are measured when nil.
+	Days func(from, to time.Time) float64
+}
+
+// LabelOptions configures how label names are mapped before aggregation
+type LabelOptions struct {
+	IgnoreCase bool
+	// Aliases maps label names to their canonical name
+	Aliases map[string]string
+	// Prefix keeps only the labels starting with the prefix
+	Prefix string
+	// Include and Exclude are regular expressions selecting labels
+	Include []string
+	Exclude []string
+}
+
+// Analyze computes the statistics of the prs
+func Analyze(prs []types.PullRequest, opts AnalyzeOptions) (types.Statistics, error) {
+	analysisOpts := analysis.Options{
+		GroupBy:       opts.GroupBy,
+		LabelPriority: opts.LabelPriority,
+		SortBy:        opts.SortBy,
+		Top:           opts.Top,
+		MinCount:      opts.MinCount,
+		Days:          opts.Days,
+	}
+	if err := analysisOpts.Validate(); err != nil {
+		return types.Statistics{}, err
+	}
+
+	authors := filter.AuthorOptions{Exclude: opts.ExcludeAuthors, ExcludeBots: opts.ExcludeBots, OnlyBots: opts.OnlyBots}
+	if err := authors.Validate(); err != nil {
+		return types.Statistics{}, err
+	}
+	prs = filter.Authors(prs, authors)
+
+	if opts.Filter != "" {
+		expr, err := filter.Parse(opts.Filter)
+		if err != nil {
+			return types.Statistics{}, err
+		}
+		if filter.RequiresSearch(expr) {
+			return types.Statistics{}, fmt.Errorf("invalid filter: base terms require the search query, use SearchPullRequests")
+		}
+		prs = filter.Apply(prs, expr)
+	}
+
+	normalizer, err := labels.New(labels.Options(opts.Labels))
+	if err != nil {
+		return types.Statistics{}, err
+	}
+
+	_, statistics, err := analysis.Analyze(normalizer.Apply(prs), analysisOpts)
+	return statistics, err
+}
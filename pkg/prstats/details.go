@@ -0,0 +1,61 @@
+package prstats
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/stats"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// DetailsOptions configures the statistics computed from the pr details
+// returned by Fetcher.FetchReviews and Fetcher.FetchSizes
+type DetailsOptions struct {
+	// SizeThresholds are the increasing exclusive upper bounds of changed
+	// lines of the XS, S, M and L buckets, 10,100,500,1000 when empty
+	SizeThresholds []int
+
+	// Days measures the time between two instants in days, calendar days
+	// when nil
+	Days func(from, to time.Time) float64
+	// Location is the timezone of the created hour and weekday of the
+	// insights, UTC when nil
+	Location *time.Location
+}
+
+// AnalyzeSizes computes the merge statistics per size bucket and the size
+// percentiles per label. Prs without a size are ignored.
+func AnalyzeSizes(prs []types.PullRequest, sizes map[int]types.PullRequestSize, opts DetailsOptions) (types.SizeReport, error) {
+	thresholds := stats.DefaultSizeThresholds
+	if len(opts.SizeThresholds) > 0 {
+		parts := make([]string, len(opts.SizeThresholds))
+		for i, threshold := range opts.SizeThresholds {
+			parts[i] = strconv.Itoa(threshold)
+		}
+		var err error
+		if thresholds, err = stats.ParseSizeThresholds(strings.Join(parts, ",")); err != nil {
+			return types.SizeReport{}, err
+		}
+	}
+	return stats.CalculateSizes(withSizes(prs, sizes), thresholds, opts.Days), nil
+}
+
+// AnalyzeInsights ranks the pr attributes by their correlation with the time
+// to merge. The sizes are optional; without them the size attributes are
+// not ranked.
+func AnalyzeInsights(prs []types.PullRequest, reviews map[int][]types.Review, sizes map[int]types.PullRequestSize, opts DetailsOptions) types.InsightsReport {
+	return stats.CalculateInsights(withSizes(prs, sizes), reviews, opts.Days, opts.Location)
+}
+
+// withSizes returns a copy of the prs with their sizes set
+func withSizes(prs []types.PullRequest, sizes map[int]types.PullRequestSize) []types.PullRequest {
+	sized := make([]types.PullRequest, len(prs))
+	for i, pr := range prs {
+		if size, ok := sizes[pr.Number]; ok {
+			pr.Size = &size
+		}
+		sized[i] = pr
+	}
+	return sized
+}
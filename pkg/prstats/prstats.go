@@ -0,0 +1,87 @@
+// Package prstats fetches the prs of GitHub repositories and computes and
+// renders their statistics. It is the library behind gh pr-stats.
+package prstats
+
+import (
+	"time"
+
+	"github.com/shufo/gh-pr-stats/internal/github"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Fetcher fetches the prs of a repository in the owner/repo format and their
+// details. Implementations other than Client can supply the prs, e.g. from
+// a cache or in tests.
+type Fetcher interface {
+	FetchPullRequests(repository string) ([]types.PullRequest, error)
+	// FetchReviews fetches the reviews of the prs, keyed by pr number, used by
+	// AnalyzeInsights
+	FetchReviews(repository string, prs []types.PullRequest) (map[int][]types.Review, error)
+	// FetchSizes fetches the sizes of the prs, keyed by pr number, used by
+	// AnalyzeSizes and AnalyzeInsights
+	FetchSizes(repository string, prs []types.PullRequest) (map[int]types.PullRequestSize, error)
+}
+
+// Options configures a Client
+type Options struct {
+	// Host is the GitHub host of repositories given as owner/repo, by default
+	// GH_HOST or the host gh is authenticated with. Repositories given as
+	// HOST/owner/repo are fetched from HOST with the token gh has for it.
+	Host string
+	// Token is the API token, by default the token gh is authenticated with
+	Token string
+	// Remote is the git remote of the current directory the repository is
+	// taken from when a method is given an empty repository, by default
+	// upstream, github, origin or the first remote
+	Remote string
+	// Concurrency is the number of parallel requests fetching reviews and sizes
+	Concurrency int
+	// CacheTTL caches the API responses for this duration when positive
+	CacheTTL time.Duration
+	// CacheDir is the directory of the cached responses
+	CacheDir string
+}
+
+// Client fetches prs and their details from the GitHub REST API
+type Client struct {
+	client *github.Client
+}
+
+var _ Fetcher = (*Client)(nil)
+
+// NewClient creates a client
+func NewClient(opts Options) (*Client, error) {
+	client, err := github.NewClient(github.ClientOptions{
+		Host:        opts.Host,
+		AuthToken:   opts.Token,
+		Remote:      opts.Remote,
+		Concurrency: opts.Concurrency,
+		CacheTTL:    opts.CacheTTL,
+		CacheDir:    opts.CacheDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client: client}, nil
+}
+
+// FetchPullRequests fetches every pr of the repository
+func (c *Client) FetchPullRequests(repository string) ([]types.PullRequest, error) {
+	return c.client.FetchPullRequests(repository)
+}
+
+// SearchPullRequests fetches the prs of the repository matching GitHub search
+// qualifiers such as label:bug. The search API returns at most 1000 prs.
+func (c *Client) SearchPullRequests(repository string, qualifiers []string) ([]types.PullRequest, error) {
+	return c.client.SearchPullRequests(repository, qualifiers)
+}
+
+// FetchReviews fetches the reviews of the prs, keyed by pr number
+func (c *Client) FetchReviews(repository string, prs []types.PullRequest) (map[int][]types.Review, error) {
+	return c.client.FetchReviews(repository, prs)
+}
+
+// FetchSizes fetches the sizes of the prs, keyed by pr number
+func (c *Client) FetchSizes(repository string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+	return c.client.FetchSizes(repository, prs)
+}
@@ -0,0 +1,116 @@
+package prstats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shufo/gh-pr-stats/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createPullRequests() []types.PullRequest {
+	now := time.Now()
+	created := now.Add(-48 * time.Hour)
+	return []types.PullRequest{
+		{Number: 1, State: "closed", User: types.User{Login: "alice", Type: "User"}, Labels: []types.Label{{Name: "bug"}}, CreatedAt: &created, ClosedAt: &now},
+		{Number: 2, State: "open", User: types.User{Login: "alice", Type: "User"}, Labels: []types.Label{{Name: "Bug"}}, CreatedAt: &created},
+		{Number: 3, State: "open", User: types.User{Login: "dependabot[bot]", Type: "Bot"}, Labels: []types.Label{{Name: "deps"}}, CreatedAt: &created},
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	stats, err := Analyze(createPullRequests(), AnalyzeOptions{
+		ExcludeBots: true,
+		Labels:      LabelOptions{IgnoreCase: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.OverallStats.Total)
+	require.Len(t, stats.LabelStats, 1, "case variants should be merged into a single label row")
+	assert.Equal(t, 2, stats.LabelStats[0].Total)
+
+	stats, err = Analyze(createPullRequests(), AnalyzeOptions{Filter: "label:deps", GroupBy: "author"})
+	require.NoError(t, err)
+	require.Len(t, stats.LabelStats, 1)
+	assert.Equal(t, "dependabot[bot]", stats.LabelStats[0].Name)
+
+	halfDays := func(from, to time.Time) float64 { return to.Sub(from).Hours() / 48 }
+	stats, err = Analyze(createPullRequests(), AnalyzeOptions{Days: halfDays})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, stats.OverallStats.AvgDaysToClose, "the close time should be measured by Days")
+
+	for _, opts := range []AnalyzeOptions{
+		{Filter: "base:main"},
+		{Filter: "label:"},
+		{GroupBy: "unknown"},
+		{SortBy: "unknown"},
+		{Top: -1},
+		{ExcludeBots: true, OnlyBots: true},
+	} {
+		_, err := Analyze(createPullRequests(), opts)
+		assert.Error(t, err, "%+v", opts)
+	}
+}
+
+// mockFetcher supplies the prs and their details without the GitHub API
+type mockFetcher struct{}
+
+func (mockFetcher) FetchPullRequests(repository string) ([]types.PullRequest, error) {
+	prs := createPullRequests()
+	prs[0].PullRequest = &types.PullRequestRef{MergedAt: prs[0].ClosedAt}
+	return prs, nil
+}
+
+func (mockFetcher) FetchReviews(repository string, prs []types.PullRequest) (map[int][]types.Review, error) {
+	return map[int][]types.Review{1: {{ID: 1, User: types.User{Login: "bob"}, State: "APPROVED"}}}, nil
+}
+
+func (mockFetcher) FetchSizes(repository string, prs []types.PullRequest) (map[int]types.PullRequestSize, error) {
+	return map[int]types.PullRequestSize{1: {Additions: 40, Deletions: 10}, 2: {Additions: 2000}}, nil
+}
+
+func TestAnalyzeDetails(t *testing.T) {
+	var fetcher Fetcher = mockFetcher{}
+	prs, err := fetcher.FetchPullRequests("owner/repo")
+	require.NoError(t, err)
+	sizes, err := fetcher.FetchSizes("owner/repo", prs)
+	require.NoError(t, err)
+	reviews, err := fetcher.FetchReviews("owner/repo", prs)
+	require.NoError(t, err)
+
+	report, err := AnalyzeSizes(prs, sizes, DetailsOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.SizeBuckets, 5)
+	assert.Equal(t, 1, report.SizeBuckets[1].Total, "the pr with 50 changed lines is small")
+	assert.Equal(t, 1, report.SizeBuckets[1].Merged)
+	assert.Equal(t, 1, report.SizeBuckets[4].Total, "the pr with 2000 changed lines is extra large")
+
+	report, err = AnalyzeSizes(prs, sizes, DetailsOptions{SizeThresholds: []int{100, 200, 300, 400}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.SizeBuckets[0].Total)
+
+	_, err = AnalyzeSizes(prs, sizes, DetailsOptions{SizeThresholds: []int{100, 50, 300, 400}})
+	assert.ErrorContains(t, err, "must be increasing")
+
+	insights := AnalyzeInsights(prs, reviews, sizes, DetailsOptions{})
+	assert.Equal(t, 1, insights.MergedPullRequests)
+}
+
+func TestRender(t *testing.T) {
+	stats, err := Analyze(createPullRequests(), AnalyzeOptions{})
+	require.NoError(t, err)
+
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		require.NoError(t, Render(&buf, stats, format), format)
+		assert.Contains(t, buf.String(), "deps", format)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderColumns(&buf, stats, "csv", []string{"label", "open"}))
+	assert.True(t, strings.HasPrefix(buf.String(), "Label,Open"), buf.String())
+
+	assert.Error(t, Render(&buf, stats, "xml"), "unsupported formats should be rejected")
+}
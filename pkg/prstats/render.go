@@ -0,0 +1,28 @@
+package prstats
+
+import (
+	"io"
+	"strings"
+
+	"github.com/shufo/gh-pr-stats/internal/render"
+	"github.com/shufo/gh-pr-stats/internal/utils"
+	"github.com/shufo/gh-pr-stats/pkg/types"
+)
+
+// Formats lists the formats supported by Render
+var Formats = render.Default.Names()
+
+// Render writes the statistics to w in the given format with the default columns
+func Render(w io.Writer, stats types.Statistics, format string) error {
+	return RenderColumns(w, stats, format, nil)
+}
+
+// RenderColumns writes the statistics to w in the given format with the
+// named columns, or the default columns when none are given
+func RenderColumns(w io.Writer, stats types.Statistics, format string, columns []string) error {
+	selected, err := utils.ParseColumns(strings.Join(columns, ","))
+	if err != nil {
+		return err
+	}
+	return render.Default.Render(w, types.NewReport(stats), format, render.Options{Columns: selected})
+}
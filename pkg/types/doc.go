@@ -0,0 +1,9 @@
+// Package types defines the prs read from the GitHub API and the reports
+// computed from them. The JSON encoding of these types is the output of
+// gh pr-stats and is versioned by SchemaVersion: fields are only added
+// within a version, and renaming or removing a field bumps it.
+package types
+
+// SchemaVersion is the version of the JSON encoding of the report types.
+// Version 2 wraps the statistics in a Report and uses camelCase keys only.
+const SchemaVersion = 2
@@ -0,0 +1,117 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Report is the JSON document of the statistics, wrapping them with the
+// metadata of the run that computed them
+type Report struct {
+	SchemaVersion int           `json:"schemaVersion" description:"Version of the schema of the report"`
+	GeneratedAt   time.Time     `json:"generatedAt" description:"Time the statistics were computed at"`
+	ToolVersion   string        `json:"toolVersion,omitempty" description:"Version of gh-pr-stats that computed the statistics"`
+	Repositories  []string      `json:"repositories" description:"Repositories the prs were fetched from, as owner/repo or HOST/owner/repo"`
+	Filters       ReportFilters `json:"filters" description:"Filters the prs were selected with"`
+	Statistics    Statistics    `json:"statistics" description:"Statistics of the selected prs"`
+}
+
+// ReportFilters are the filters the prs were selected with
+type ReportFilters struct {
+	Filter         string     `json:"filter,omitempty" description:"Filter expression of --filter"`
+	Since          *time.Time `json:"since,omitempty" description:"Start of the creation window of the prs"`
+	ExcludeAuthors []string   `json:"excludeAuthors,omitempty" description:"Authors whose prs are excluded"`
+	ExcludeBots    bool       `json:"excludeBots,omitempty" description:"Whether the prs of bots are excluded"`
+	OnlyBots       bool       `json:"onlyBots,omitempty" description:"Whether only the prs of bots are included"`
+	GroupBy        string     `json:"groupBy,omitempty" description:"Attribute the rows of the label statistics are grouped by"`
+}
+
+// NewReport wraps the statistics in a report of the current schema version
+func NewReport(stats Statistics) Report {
+	return Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Repositories:  []string{},
+		Statistics:    stats,
+	}
+}
+
+// ReadReport decodes a report. Statistics of schema version 1, written
+// without the envelope, are returned in a report of version 1.
+func ReadReport(r io.Reader) (Report, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var probe struct {
+		SchemaVersion *int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Report{}, err
+	}
+
+	if probe.SchemaVersion == nil {
+		// Keys are matched case insensitively, so the version 1 keys such
+		// as AvgDaysToClose decode into the current fields
+		report := Report{SchemaVersion: 1, Repositories: []string{}}
+		if err := json.Unmarshal(data, &report.Statistics); err != nil {
+			return Report{}, err
+		}
+		return report, nil
+	}
+
+	if *probe.SchemaVersion > SchemaVersion {
+		return Report{}, fmt.Errorf("unsupported schema version %d, this version reads up to %d", *probe.SchemaVersion, SchemaVersion)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// LegacyLabelStat is a LabelStat with the keys of schema version 1
+type LegacyLabelStat struct {
+	Name              string  `json:"name"`
+	Open              int     `json:"open"`
+	Closed            int     `json:"closed"`
+	Merged            int     `json:"merged"`
+	Total             int     `json:"total"`
+	OpenPercentage    float64 `json:"openPercentage"`
+	AvgDaysToClose    float64 `json:"AvgDaysToClose"`
+	MedianDaysToClose float64 `json:"MedianDaysToClose"`
+	P90DaysToClose    float64 `json:"P90DaysToClose"`
+}
+
+// LegacyOverallStats is an OverallStats with the keys of schema version 1
+type LegacyOverallStats struct {
+	Total             int     `json:"total"`
+	Open              int     `json:"open"`
+	Closed            int     `json:"closed"`
+	Merged            int     `json:"merged"`
+	OpenPercentage    float64 `json:"openPercentage"`
+	AvgDaysToClose    float64 `json:"AvgDaysToClose"`
+	MedianDaysToClose float64 `json:"MedianDaysToClose"`
+	P90DaysToClose    float64 `json:"P90DaysToClose"`
+}
+
+// LegacyStatistics is the JSON document of schema version 1
+type LegacyStatistics struct {
+	LabelStats   []LegacyLabelStat  `json:"labelStats"`
+	OverallStats LegacyOverallStats `json:"overallStats"`
+}
+
+// Legacy returns the statistics in the shape of schema version 1
+func (s Statistics) Legacy() LegacyStatistics {
+	legacy := LegacyStatistics{
+		LabelStats:   make([]LegacyLabelStat, len(s.LabelStats)),
+		OverallStats: LegacyOverallStats(s.OverallStats),
+	}
+	for i, stat := range s.LabelStats {
+		legacy.LabelStats[i] = LegacyLabelStat(stat)
+	}
+	return legacy
+}
@@ -1,18 +1,49 @@
 package types
 
 import (
+	"strings"
 	"time"
 )
 
 // PullRequest represents a GitHub pr with relevant fields
 type PullRequest struct {
-	Number      int        `json:"number"`
-	Title       string     `json:"title"`
-	State       string     `json:"state"`
-	Labels      []Label    `json:"labels"`
-	PullRequest *struct{}  `json:"pull_request,omitempty"`
-	CreatedAt   *time.Time `json:"created_at"`
-	ClosedAt    *time.Time `json:"closed_at"`
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	State       string          `json:"state"`
+	User        User            `json:"user"`
+	Labels      []Label         `json:"labels"`
+	PullRequest *PullRequestRef `json:"pull_request,omitempty"`
+	CreatedAt   *time.Time      `json:"created_at"`
+	UpdatedAt   *time.Time      `json:"updated_at"`
+	ClosedAt    *time.Time      `json:"closed_at"`
+	// Size is only set when the pr details were fetched
+	Size *PullRequestSize `json:"size,omitempty"`
+}
+
+// PullRequestSize holds the size of the changes of a pr
+type PullRequestSize struct {
+	Additions    int `json:"additions"`
+	Deletions    int `json:"deletions"`
+	ChangedFiles int `json:"changed_files"`
+	Commits      int `json:"commits"`
+}
+
+// Lines returns the number of changed lines
+func (s PullRequestSize) Lines() int {
+	return s.Additions + s.Deletions
+}
+
+// PullRequestRef holds the pull request specific fields of an issue
+type PullRequestRef struct {
+	MergedAt *time.Time `json:"merged_at,omitempty"`
+}
+
+// MergedAt returns when the pr was merged, or nil if it was not merged
+func (pr PullRequest) MergedAt() *time.Time {
+	if pr.PullRequest == nil {
+		return nil
+	}
+	return pr.PullRequest.MergedAt
 }
 
 // Label represents a GitHub pr label
@@ -20,31 +51,154 @@ type Label struct {
 	Name string `json:"name"`
 }
 
+// User represents a GitHub user
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+// IsBot reports whether the user is a bot or an app account
+func (u User) IsBot() bool {
+	return u.Type == "Bot" || strings.HasSuffix(u.Login, "[bot]")
+}
+
+// Review represents a review submitted on a pr
+type Review struct {
+	ID          int64      `json:"id"`
+	User        User       `json:"user"`
+	State       string     `json:"state"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
 const UnlabeledLabel = "*unlabeled*"
 
 // LabelStat stores statistics for a specific label
 type LabelStat struct {
-	Name              string  `json:"name"`
-	Open              int     `json:"open"`
-	Closed            int     `json:"closed"`
-	Total             int     `json:"total"`
-	OpenPercentage    float64 `json:"openPercentage"`
-	AvgDaysToClose    float64 `json:"AvgDaysToClose"`
-	MedianDaysToClose float64 `json:"MedianDaysToClose"`
+	Name              string  `json:"name" description:"Label, or the group of the rows when grouped by another attribute"`
+	Open              int     `json:"open" description:"Number of open prs"`
+	Closed            int     `json:"closed" description:"Number of closed prs, merged or not"`
+	Merged            int     `json:"merged" description:"Number of merged prs"`
+	Total             int     `json:"total" description:"Number of prs"`
+	OpenPercentage    float64 `json:"openPercentage" description:"Percentage of open prs, 0 without prs"`
+	AvgDaysToClose    float64 `json:"avgDaysToClose" description:"Average days from creation to close of the closed prs"`
+	MedianDaysToClose float64 `json:"medianDaysToClose" description:"Median days from creation to close of the closed prs"`
+	P90DaysToClose    float64 `json:"p90DaysToClose" description:"90th percentile of the days from creation to close of the closed prs"`
 }
 
 // OverallStats stores the overall pr statistics
 type OverallStats struct {
-	Total             int     `json:"total"`
-	Open              int     `json:"open"`
-	Closed            int     `json:"closed"`
-	OpenPercentage    float64 `json:"openPercentage"`
-	AvgDaysToClose    float64 `json:"AvgDaysToClose"`
-	MedianDaysToClose float64 `json:"MedianDaysToClose"`
+	Total             int     `json:"total" description:"Number of prs"`
+	Open              int     `json:"open" description:"Number of open prs"`
+	Closed            int     `json:"closed" description:"Number of closed prs, merged or not"`
+	Merged            int     `json:"merged" description:"Number of merged prs"`
+	OpenPercentage    float64 `json:"openPercentage" description:"Percentage of open prs, 0 without prs"`
+	AvgDaysToClose    float64 `json:"avgDaysToClose" description:"Average days from creation to close of the closed prs"`
+	MedianDaysToClose float64 `json:"medianDaysToClose" description:"Median days from creation to close of the closed prs"`
+	P90DaysToClose    float64 `json:"p90DaysToClose" description:"90th percentile of the days from creation to close of the closed prs"`
 }
 
 // Statistics combines both label and overall statistics
 type Statistics struct {
-	LabelStats   []LabelStat  `json:"labelStats"`
-	OverallStats OverallStats `json:"overallStats"`
+	LabelStats   []LabelStat  `json:"labelStats" description:"Statistics of every label, or group"`
+	OverallStats OverallStats `json:"overallStats" description:"Statistics of all prs"`
+}
+
+// AgeBucket counts the open prs whose age falls in a range
+type AgeBucket struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// LabelAging stores the age distribution of the open prs of a label
+type LabelAging struct {
+	Name    string      `json:"name"`
+	Open    int         `json:"open"`
+	Stale   int         `json:"stale"`
+	Buckets []AgeBucket `json:"buckets"`
+}
+
+// OpenPullRequest is an open pr listed in the aging report
+type OpenPullRequest struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	Author       string     `json:"author"`
+	Labels       []string   `json:"labels"`
+	CreatedAt    *time.Time `json:"createdAt"`
+	LastActivity *time.Time `json:"lastActivity"`
+	AgeDays      float64    `json:"ageDays"`
+	Stale        bool       `json:"stale"`
+}
+
+// AgingReport combines the age distribution of open prs and the oldest ones
+type AgingReport struct {
+	StaleAfterDays float64           `json:"staleAfterDays"`
+	LabelAging     []LabelAging      `json:"labelAging"`
+	OverallAging   LabelAging        `json:"overallAging"`
+	Oldest         []OpenPullRequest `json:"oldest"`
+}
+
+// SizeBucketStat stores the statistics of the prs in a size bucket
+type SizeBucketStat struct {
+	Name              string  `json:"name"`
+	MaxLines          int     `json:"maxLines,omitempty"`
+	Total             int     `json:"total"`
+	Merged            int     `json:"merged"`
+	MergeRate         float64 `json:"mergeRate"`
+	MedianDaysToMerge float64 `json:"medianDaysToMerge"`
+}
+
+// LabelSizeStat stores the size percentiles of the prs of a label
+type LabelSizeStat struct {
+	Name              string  `json:"name"`
+	Total             int     `json:"total"`
+	MedianLines       float64 `json:"medianLines"`
+	P90Lines          float64 `json:"p90Lines"`
+	MedianFiles       float64 `json:"medianFiles"`
+	P90Files          float64 `json:"p90Files"`
+	MedianCommits     float64 `json:"medianCommits"`
+	MedianDaysToMerge float64 `json:"medianDaysToMerge"`
+}
+
+// SizeReport combines the statistics per size bucket and per label
+type SizeReport struct {
+	SizeBuckets []SizeBucketStat `json:"sizeBuckets"`
+	LabelSizes  []LabelSizeStat  `json:"labelSizes"`
+}
+
+// Correlation stores the rank correlation of a pr attribute with time to merge
+type Correlation struct {
+	Attribute   string  `json:"attribute"`
+	Coefficient float64 `json:"coefficient"`
+	Strength    string  `json:"strength"`
+	Samples     int     `json:"samples"`
+}
+
+// InsightsReport lists the correlations ranked by strength
+type InsightsReport struct {
+	MergedPullRequests int           `json:"mergedPullRequests"`
+	Correlations       []Correlation `json:"correlations"`
+}
+
+// LabelCount stores how often a label is used, and how often it is the
+// only label of a pr
+type LabelCount struct {
+	Name      string `json:"name"`
+	Total     int    `json:"total"`
+	Exclusive int    `json:"exclusive"`
+}
+
+// LabelPair stores how often two labels are used on the same pr
+type LabelPair struct {
+	Labels [2]string `json:"labels"`
+	Count  int       `json:"count"`
+}
+
+// LabelMatrix stores the label co-occurrence counts. Matrix[i][j] is the
+// number of prs labeled with both Labels[i] and Labels[j]; the diagonal
+// holds the label totals.
+type LabelMatrix struct {
+	PullRequests int          `json:"pullRequests"`
+	Labels       []LabelCount `json:"labels"`
+	Matrix       [][]int      `json:"matrix"`
+	Pairs        []LabelPair  `json:"pairs"`
 }